@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"go.opentelemetry.io/otel/api/trace"
 )
@@ -29,16 +31,7 @@ func (c *Client) UploadImage(ctx context.Context, body io.Reader) (string, error
 		return "", err
 	}
 
-	if res.StatusCode >= 500 {
-		// TODO parse messages out of error response
-		err = fmt.Errorf("unexpected server error %d", res.StatusCode)
-		span.RecordError(ctx, err)
-		return "", err
-	}
-
-	if res.StatusCode >= 400 {
-		// TODO parse messages out of error response
-		err = fmt.Errorf("unexpected client error %d", res.StatusCode)
+	if err := checkResponse(res); err != nil {
 		span.RecordError(ctx, err)
 		return "", err
 	}
@@ -56,3 +49,35 @@ func (c *Client) UploadImage(ctx context.Context, body io.Reader) (string, error
 	span.SetAttributes(docIDKey(idResp.Document.ID))
 	return idResp.Document.ID, nil
 }
+
+// ImageURL returns the CDN URL for an image asset.
+func (c *Client) ImageURL(id string) string {
+	urlID := strings.TrimPrefix(id, "image-")
+	if lastDashIdx := strings.LastIndex(urlID, "-"); lastDashIdx != -1 {
+		urlID = urlID[:lastDashIdx] + "." + urlID[lastDashIdx+1:]
+	}
+
+	return fmt.Sprintf("https://cdn.sanity.io/images/%s/%s/%s", c.ProjectID, c.Dataset, urlID)
+}
+
+// ImageAssetID returns the ID of the image asset that a CDN URL refers to, if it is an image in
+// this client's project and dataset.
+func (c *Client) ImageAssetID(imageURL string) (string, bool) {
+	u, err := url.Parse(imageURL)
+	if err != nil || u.Host != "cdn.sanity.io" {
+		return "", false
+	}
+
+	prefix := fmt.Sprintf("/images/%s/%s/", c.ProjectID, c.Dataset)
+	if !strings.HasPrefix(u.Path, prefix) {
+		return "", false
+	}
+
+	urlID := strings.TrimPrefix(u.Path, prefix)
+	dotIdx := strings.LastIndex(urlID, ".")
+	if dotIdx == -1 || strings.Contains(urlID, "/") {
+		return "", false
+	}
+
+	return "image-" + urlID[:dotIdx] + "-" + urlID[dotIdx+1:], true
+}
@@ -10,7 +10,11 @@ type Content interface{}
 
 type Block struct {
 	Type    string `json:"_type"`
+	Key     string `json:"_key,omitempty"`
 	Content interface{}
+
+	// fields of a registered type that its content doesn't have
+	unknown map[string]json.RawMessage
 }
 
 func New(style string, opts ...BlockOption) Block {
@@ -33,6 +37,9 @@ func (b Block) MarshalJSON() ([]byte, error) {
 	m := map[string]interface{}{
 		"_type": b.Type,
 	}
+	if b.Key != "" {
+		m["_key"] = b.Key
+	}
 
 	if content, ok := b.Content.(map[string]interface{}); ok {
 		for k, v := range content {
@@ -52,6 +59,9 @@ func (b Block) MarshalJSON() ([]byte, error) {
 			m[tagVals[0]] = val.FieldByName(name).Interface()
 		}
 	}
+	for k, v := range b.unknown {
+		m[k] = v
+	}
 
 	return json.Marshal(m)
 }
@@ -59,37 +69,31 @@ func (b Block) MarshalJSON() ([]byte, error) {
 func (b *Block) UnmarshalJSON(data []byte) error {
 	var typeVal struct {
 		Type string `json:"_type"`
+		Key  string `json:"_key"`
 	}
 	if err := json.Unmarshal(data, &typeVal); err != nil {
 		return err
 	}
 
 	b.Type = typeVal.Type
+	b.Key = typeVal.Key
 
-	switch b.Type {
-	case "block":
-		var bc BlockContent
-		if err := json.Unmarshal(data, &bc); err != nil {
+	if content, ok := newContent(b.Type); ok {
+		if err := json.Unmarshal(data, content); err != nil {
 			return err
 		}
-		b.Content = &bc
-		return nil
-	case "span":
-		var sc SpanContent
-		if err := json.Unmarshal(data, &sc); err != nil {
-			return err
-		}
-		b.Content = &sc
-		return nil
-	default:
-		m := map[string]interface{}{}
-		if err := json.Unmarshal(data, &m); err != nil {
-			return err
-		}
-		delete(m, "_type")
-		b.Content = m
-		return nil
+		b.Content = content
+		return unknownFields(data, content, &b.unknown)
 	}
+
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	delete(m, "_type")
+	delete(m, "_key")
+	b.Content = m
+	return nil
 }
 
 type BlockContent struct {
@@ -98,6 +102,9 @@ type BlockContent struct {
 	MarkDefs []MarkDef `json:"markDefs"`
 	ListItem string    `json:"listItem,omitempty"`
 	Level    int       `json:"level,omitempty"`
+	// ListStart is the number of the first item of a numbered list, set on that item if the list
+	// doesn't start at 1.
+	ListStart int `json:"listStart,omitempty"`
 }
 
 type BlockOption interface {
@@ -132,6 +139,9 @@ type MarkDef struct {
 	Type string `json:"_type"`
 	Key  string `json:"_key"`
 	Data
+
+	// fields of a registered type that its data doesn't have
+	unknown map[string]json.RawMessage
 }
 
 func (md MarkDef) MarshalJSON() ([]byte, error) {
@@ -158,10 +168,66 @@ func (md MarkDef) MarshalJSON() ([]byte, error) {
 			m[tagVals[0]] = val.FieldByName(name).Interface()
 		}
 	}
+	for k, v := range md.unknown {
+		m[k] = v
+	}
 
 	return json.Marshal(m)
 }
 
+func (md *MarkDef) UnmarshalJSON(data []byte) error {
+	var typeVal struct {
+		Type string `json:"_type"`
+		Key  string `json:"_key"`
+	}
+	if err := json.Unmarshal(data, &typeVal); err != nil {
+		return err
+	}
+
+	md.Type = typeVal.Type
+	md.Key = typeVal.Key
+
+	if d, ok := newMarkDefData(md.Type); ok {
+		if err := json.Unmarshal(data, d); err != nil {
+			return err
+		}
+		md.Data = d
+		return unknownFields(data, d, &md.unknown)
+	}
+
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	delete(m, "_type")
+	delete(m, "_key")
+	md.Data = m
+	return nil
+}
+
+// unknownFields keeps the fields of a JSON object that aren't in v's struct type, so they aren't
+// lost when it's encoded again.
+func unknownFields(data []byte, v interface{}, unknown *map[string]json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	delete(fields, "_type")
+	delete(fields, "_key")
+
+	t := reflect.TypeOf(v).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		delete(fields, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+
+	if len(fields) > 0 {
+		*unknown = fields
+	} else {
+		*unknown = nil
+	}
+	return nil
+}
+
 type LinkData struct {
 	Href string `json:"href"`
 }
@@ -170,20 +236,3 @@ type SpanContent struct {
 	Text  string   `json:"text"`
 	Marks []string `json:"marks,omitempty"`
 }
-
-func ToPlainText(blocks []Block) string {
-	var s strings.Builder
-	for _, b := range blocks {
-		if bc, ok := b.Content.(*BlockContent); ok {
-			if s.Len() > 0 {
-				s.WriteString("\n\n")
-			}
-			for _, span := range bc.Children {
-				if sc, ok := span.Content.(*SpanContent); ok {
-					s.WriteString(sc.Text)
-				}
-			}
-		}
-	}
-	return s.String()
-}
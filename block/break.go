@@ -0,0 +1,7 @@
+package block
+
+const TypeBreak = "break"
+
+type BreakContent struct {
+	Style string `json:"style"`
+}
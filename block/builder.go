@@ -2,13 +2,59 @@ package block
 
 import (
 	"fmt"
+	"strings"
 )
 
 type Builder struct {
+	// StrictBlocks drops text and inline objects that are added outside of a text block and
+	// records an error for them, instead of starting a normal block to hold them.
+	StrictBlocks bool
+
 	bs            []Block
 	current       *Block
 	curSpan       *Block
 	listItemStack []string
+	// the number the next item of each list starts at, or 0 once it has an item
+	listStarts []int
+	errs       BuildErrors
+}
+
+// BuildError describes content that was added to a Builder where it doesn't fit, such as text
+// outside of any text block.
+type BuildError struct {
+	// Index is the position in the output of the block that was being built.
+	Index   int
+	Message string
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("block %d: %s", e.Index, e.Message)
+}
+
+// BuildErrors collects every problem recorded by a Builder.
+type BuildErrors []*BuildError
+
+func (es BuildErrors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return fmt.Sprintf("invalid document structure: %s", strings.Join(msgs, "; "))
+}
+
+func (b *Builder) errorf(format string, args ...interface{}) {
+	b.errs = append(b.errs, &BuildError{
+		Index:   len(b.bs),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Err returns the problems recorded while building, or nil if there were none.
+func (b *Builder) Err() error {
+	if len(b.errs) == 0 {
+		return nil
+	}
+	return b.errs
 }
 
 func (b *Builder) StartBlock(style string) {
@@ -19,12 +65,12 @@ func (b *Builder) StartBlock(style string) {
 }
 
 func (b *Builder) EndBlock() {
+	// text left over from outside of a block may start one
+	b.EndSpan()
 	if b.current == nil {
 		return
 	}
 
-	b.EndSpan()
-
 	if bc, ok := b.current.Content.(*BlockContent); ok && len(bc.Children) == 0 {
 		return
 	}
@@ -83,13 +129,57 @@ func (b *Builder) EndSpan() {
 		return
 	}
 
-	// TODO can there not be a current block?
-	if b.current.Type == "block" {
-		bc := b.current.Content.(*BlockContent)
+	if bc := b.textBlock("text"); bc != nil {
 		bc.Children = append(bc.Children, *b.curSpan)
-		b.curSpan = nil
-	} else {
-		panic("trying to add a span to a custom block type")
+	}
+	b.curSpan = nil
+}
+
+// textBlock returns the content of the current block. Outside of a text block, it starts a normal
+// block, or with StrictBlocks, records an error and returns nil.
+func (b *Builder) textBlock(what string) *BlockContent {
+	if bc := b.currentText(); bc != nil {
+		return bc
+	}
+	if b.StrictBlocks {
+		if b.current != nil {
+			b.errorf("%s added to a %q block", what, b.current.Type)
+		} else {
+			b.errorf("%s added outside of a block", what)
+		}
+		return nil
+	}
+
+	newBlock := New("normal")
+	b.current = &newBlock
+	return newBlock.Content.(*BlockContent)
+}
+
+// AddInlineObject adds a child of a custom type to the current text block, between the spans
+// around it. Any marks that are open stay open for the text after it.
+func (b *Builder) AddInlineObject(typeName string, content interface{}) {
+	var marks []string
+	if b.curSpan != nil {
+		if sc, ok := b.curSpan.Content.(*SpanContent); ok {
+			marks = sc.Marks
+		}
+		b.EndSpan()
+	}
+
+	if bc := b.textBlock(fmt.Sprintf("inline %q", typeName)); bc != nil {
+		bc.Children = append(bc.Children, Block{
+			Type:    typeName,
+			Content: content,
+		})
+	}
+
+	if len(marks) > 0 {
+		b.curSpan = &Block{
+			Type: "span",
+			Content: &SpanContent{
+				Marks: marks,
+			},
+		}
 	}
 }
 
@@ -110,10 +200,25 @@ func (b *Builder) AppendText(text string) {
 
 func (b *Builder) StartList(listItem string) {
 	b.listItemStack = append(b.listItemStack, listItem)
+	b.listStarts = append(b.listStarts, 0)
+}
+
+// StartNumberedList starts a list of "number" items, numbered from start. Lists that start at 1
+// or lower are numbered like any other list.
+func (b *Builder) StartNumberedList(start int) {
+	b.StartList("number")
+	if start > 1 {
+		b.listStarts[len(b.listStarts)-1] = start
+	}
 }
 
 func (b *Builder) EndList() {
+	if len(b.listItemStack) == 0 {
+		b.errorf("list ended without being started")
+		return
+	}
 	b.listItemStack = b.listItemStack[:len(b.listItemStack)-1]
+	b.listStarts = b.listStarts[:len(b.listStarts)-1]
 }
 
 func (b *Builder) StartListItem() {
@@ -125,12 +230,31 @@ func (b *Builder) StartListItem() {
 	bc := b.current.Content.(*BlockContent)
 	bc.ListItem = b.listItemStack[len(b.listItemStack)-1]
 	bc.Level = len(b.listItemStack)
+	bc.ListStart = b.listStarts[len(b.listStarts)-1]
+	b.listStarts[len(b.listStarts)-1] = 0
 }
 
 func (b *Builder) EndListItem() {
 	b.EndBlock()
 }
 
+// StartListContinuation starts a block for content that continues the current list item, such
+// as a second paragraph. The block has the level of the list item but isn't a list item itself.
+// Outside of a list, it starts an ordinary block.
+func (b *Builder) StartListContinuation(style string) {
+	b.StartBlock(style)
+	b.current.Content.(*BlockContent).Level = len(b.listItemStack)
+}
+
+// currentText returns the content of the current block if it's a text block.
+func (b *Builder) currentText() *BlockContent {
+	if b.current == nil {
+		return nil
+	}
+	bc, _ := b.current.Content.(*BlockContent)
+	return bc
+}
+
 func (b *Builder) AddCustomBlock(typeName string, content interface{}) {
 	b.EndBlock()
 
@@ -140,13 +264,65 @@ func (b *Builder) AddCustomBlock(typeName string, content interface{}) {
 	})
 }
 
+// InsertCustomBlock adds a custom block in the middle of the current text block, splitting the
+// text block around it. Text added afterwards goes into a new block with the same style, and any
+// marks that were open stay open. Within a list item, the new block continues the item.
+func (b *Builder) InsertCustomBlock(typeName string, content interface{}) {
+	if b.current == nil {
+		b.AddCustomBlock(typeName, content)
+		return
+	}
+
+	var marks []string
+	if b.curSpan != nil {
+		if sc, ok := b.curSpan.Content.(*SpanContent); ok {
+			marks = append(marks, sc.Marks...)
+		}
+	}
+
+	prev, isText := b.current.Content.(*BlockContent)
+	b.EndBlock()
+	b.current = nil
+	b.AddCustomBlock(typeName, content)
+	if !isText {
+		return
+	}
+
+	next := New(prev.Style)
+	nbc := next.Content.(*BlockContent)
+	// the rest of a list item continues it rather than starting a new one
+	nbc.Level = prev.Level
+	for _, md := range prev.MarkDefs {
+		if hasMark(marks, md.Key) {
+			nbc.MarkDefs = append(nbc.MarkDefs, md)
+		}
+	}
+	b.current = &next
+
+	if len(marks) > 0 {
+		b.curSpan = &Block{
+			Type: "span",
+			Content: &SpanContent{
+				Marks: marks,
+			},
+		}
+	}
+}
+
+// AddMarkDef adds a mark definition to the current text block and returns its key. Like text, it
+// needs a text block, so outside of one it returns an empty key if StrictBlocks is set.
 func (b *Builder) AddMarkDef(typeName string, data interface{}) string {
-	bc, ok := b.current.Content.(*BlockContent)
-	if !ok {
+	bc := b.textBlock(fmt.Sprintf("mark %q", typeName))
+	if bc == nil {
 		return ""
 	}
 
-	markKey := fmt.Sprintf("mark%d", len(bc.MarkDefs)+1)
+	n := len(bc.MarkDefs) + 1
+	markKey := fmt.Sprintf("mark%d", n)
+	for b.hasMarkDef(bc, markKey) {
+		n++
+		markKey = fmt.Sprintf("mark%d", n)
+	}
 	bc.MarkDefs = append(bc.MarkDefs, MarkDef{
 		Type: typeName,
 		Key:  markKey,
@@ -155,6 +331,15 @@ func (b *Builder) AddMarkDef(typeName string, data interface{}) string {
 	return markKey
 }
 
+func (b *Builder) hasMarkDef(bc *BlockContent, key string) bool {
+	for _, md := range bc.MarkDefs {
+		if md.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *Builder) Blocks() []Block {
 	b.EndBlock()
 	return b.bs
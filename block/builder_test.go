@@ -0,0 +1,100 @@
+package block
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/russross/blackfriday/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderErrors(t *testing.T) {
+	b := &Builder{StrictBlocks: true}
+	assert.NotPanics(t, func() {
+		b.AppendText("loose text")
+		b.EndSpan()
+		assert.Equal(t, "", b.AddMarkDef("link", &LinkData{Href: "https://example.com"}))
+		b.EndList()
+
+		b.AddCustomBlock(TypeCode, &CodeContent{Code: "x"})
+		b.AddInlineObject(TypeMention, &MentionContent{Username: "someone"})
+
+		b.StartBlock("normal")
+		b.AppendText("fine")
+	})
+
+	assert.Equal(t, []Block{
+		{Type: TypeCode, Content: &CodeContent{Code: "x"}},
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style:    "normal",
+				Children: []Block{{Type: "span", Content: &SpanContent{Text: "fine"}}},
+				MarkDefs: []MarkDef{},
+			},
+		},
+	}, b.Blocks())
+
+	err := b.Err()
+	assert.Equal(t, BuildErrors{
+		{Index: 0, Message: "text added outside of a block"},
+		{Index: 0, Message: `mark "link" added outside of a block`},
+		{Index: 0, Message: "list ended without being started"},
+		{Index: 1, Message: `inline "mention" added outside of a block`},
+	}, err)
+	assert.EqualError(t, err, `invalid document structure: block 0: text added outside of a block; `+
+		`block 0: mark "link" added outside of a block; block 0: list ended without being started; `+
+		`block 1: inline "mention" added outside of a block`)
+}
+
+func TestBuilderNoErrors(t *testing.T) {
+	b := &Builder{}
+	b.StartBlock("normal")
+	b.AppendText("text")
+	b.EndBlock()
+	assert.Len(t, b.Blocks(), 1)
+	assert.NoError(t, b.Err())
+}
+
+// dividerRule replaces emphasis with a custom block, leaving the text after it outside of any
+// block.
+func dividerRule(b *Builder, node *blackfriday.Node, entering bool) (blackfriday.WalkStatus, bool) {
+	if node.Type != blackfriday.Emph {
+		return blackfriday.GoToNext, false
+	}
+	b.AddCustomBlock("divider", nil)
+	return blackfriday.SkipChildren, true
+}
+
+func TestMarkdownBuildErrors(t *testing.T) {
+	mc := NewMarkdownConverter(WithMarkdownRules(dividerRule), WithStrictBlocks())
+	out, err := mc.ToBlocks("before *x* after")
+	assert.Nil(t, out)
+
+	var buildErrs BuildErrors
+	assert.True(t, errors.As(err, &buildErrs))
+	assert.Equal(t, BuildErrors{{Index: 2, Message: "text added outside of a block"}}, buildErrs)
+
+	mc = NewMarkdownConverter(WithMarkdownRules(dividerRule))
+	out, err = mc.ToBlocks("before *x* after")
+	assert.NoError(t, err)
+	assert.Equal(t, []Block{
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style:    "normal",
+				Children: []Block{{Type: "span", Content: &SpanContent{Text: "before "}}},
+				MarkDefs: []MarkDef{},
+			},
+		},
+		{Type: "divider"},
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style:    "normal",
+				Children: []Block{{Type: "span", Content: &SpanContent{Text: " after"}}},
+				MarkDefs: []MarkDef{},
+			},
+		},
+	}, out)
+}
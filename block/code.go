@@ -1,6 +1,211 @@
 package block
 
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const TypeCode = "code"
+
 type CodeContent struct {
-	Language string `json:"language,omitempty"`
+	Language         string          `json:"language,omitempty"`
+	Filename         string          `json:"filename,omitempty"`
+	HighlightedLines []int           `json:"highlightedLines,omitempty"`
+	Attributes       []CodeAttribute `json:"attributes,omitempty"`
+	// Indented is set for code blocks that were indented instead of fenced.
+	Indented bool   `json:"indented,omitempty"`
 	Code     string `json:"code"`
 }
+
+// CodeAttribute is an extra key/value pair from a code block's info string. Flags without a value
+// have an empty value.
+type CodeAttribute struct {
+	Type  string `json:"_type"`
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+// Attribute returns the value of an attribute from the code block's info string.
+func (c *CodeContent) Attribute(key string) (string, bool) {
+	for _, attr := range c.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// ParseCodeInfo reads the info string of a fenced code block, like `go title="main.go" {3-5}`.
+// The first word is the language, a title or filename attribute sets the filename, and line
+// numbers and ranges in braces or a highlight attribute are highlighted. Anything else is kept as
+// an attribute. The returned content has no code.
+func ParseCodeInfo(info string) *CodeContent {
+	cc := &CodeContent{}
+
+	lines := make(map[int]bool)
+	for i, field := range splitCodeInfo(info) {
+		if strings.HasPrefix(field, "{") {
+			parseLineRanges(field, lines)
+			continue
+		}
+
+		key, value := field, ""
+		if eq := strings.IndexByte(field, '='); eq != -1 {
+			key, value = field[:eq], field[eq+1:]
+		} else if i == 0 {
+			// the language can have line ranges right after it, like go{3-5}
+			if brace := strings.IndexByte(field, '{'); brace != -1 {
+				parseLineRanges(field[brace:], lines)
+				field = field[:brace]
+			}
+			cc.Language = field
+			continue
+		}
+
+		switch key {
+		case "title", "filename", "file":
+			cc.Filename = value
+		case "highlight", "hl_lines":
+			parseLineRanges(value, lines)
+		default:
+			cc.Attributes = append(cc.Attributes, CodeAttribute{
+				Type:  "codeAttribute",
+				Key:   key,
+				Value: value,
+			})
+		}
+	}
+
+	for line := range lines {
+		cc.HighlightedLines = append(cc.HighlightedLines, line)
+	}
+	sort.Ints(cc.HighlightedLines)
+
+	return cc
+}
+
+// Info returns an info string for the code block that ParseCodeInfo can read back.
+func (c *CodeContent) Info() string {
+	var fields []string
+	if c.Language != "" {
+		fields = append(fields, c.Language)
+	}
+	if c.Filename != "" {
+		fields = append(fields, "title="+quoteCodeInfo(c.Filename))
+	}
+	if len(c.HighlightedLines) > 0 {
+		fields = append(fields, "{"+formatLineRanges(c.HighlightedLines)+"}")
+	}
+	for _, attr := range c.Attributes {
+		if attr.Value == "" {
+			fields = append(fields, attr.Key)
+		} else {
+			fields = append(fields, attr.Key+"="+quoteCodeInfo(attr.Value))
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// splitCodeInfo splits an info string on spaces, except inside quotes or braces. Quotes are
+// removed.
+func splitCodeInfo(info string) []string {
+	var fields []string
+	var field strings.Builder
+	var quote rune
+	inField := false
+	braces := 0
+
+	for _, r := range info {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				field.WriteRune(r)
+			}
+			continue
+		case r == '"' || r == '\'':
+			quote = r
+		case unicode.IsSpace(r) && braces == 0:
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+			continue
+		case r == '{':
+			braces++
+			field.WriteRune(r)
+		case r == '}':
+			braces--
+			field.WriteRune(r)
+		default:
+			field.WriteRune(r)
+		}
+		inField = true
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// maxHighlightedLine is the highest line number that can be highlighted. Info strings come from
+// posts, so this keeps a range like {1-200000000} from using up all of the memory.
+const maxHighlightedLine = 10000
+
+// parseLineRanges adds line numbers and ranges like "1,3-5" or "{1 3-5}" to lines. Anything that
+// isn't a valid line or range, or goes past maxHighlightedLine, is ignored.
+func parseLineRanges(s string, lines map[int]bool) {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '{' || r == '}' || r == '[' || r == ']' || unicode.IsSpace(r)
+	})
+	for _, part := range parts {
+		from, to := part, part
+		if dash := strings.IndexByte(part, '-'); dash != -1 {
+			from, to = part[:dash], part[dash+1:]
+		}
+
+		start, err := strconv.Atoi(from)
+		if err != nil || start < 1 {
+			continue
+		}
+		end, err := strconv.Atoi(to)
+		if err != nil || end < start || end > maxHighlightedLine {
+			continue
+		}
+		for line := start; line <= end; line++ {
+			lines[line] = true
+		}
+	}
+}
+
+// formatLineRanges collapses consecutive line numbers back into ranges.
+func formatLineRanges(lines []int) string {
+	var parts []string
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, strconv.Itoa(lines[i])+"-"+strconv.Itoa(lines[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(lines[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+func quoteCodeInfo(s string) string {
+	if strings.IndexFunc(s, unicode.IsSpace) == -1 && !strings.ContainsAny(s, `"'{}`) {
+		return s
+	}
+	if strings.Contains(s, `"`) {
+		return "'" + s + "'"
+	}
+	return `"` + s + `"`
+}
@@ -0,0 +1,131 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCodeInfo(t *testing.T) {
+	attr := func(key, value string) CodeAttribute {
+		return CodeAttribute{Type: "codeAttribute", Key: key, Value: value}
+	}
+
+	cases := []struct {
+		info     string
+		expected *CodeContent
+	}{
+		{
+			info:     "",
+			expected: &CodeContent{},
+		},
+		{
+			info:     "go",
+			expected: &CodeContent{Language: "go"},
+		},
+		{
+			info: "go title=main.go {3-5}",
+			expected: &CodeContent{
+				Language:         "go",
+				Filename:         "main.go",
+				HighlightedLines: []int{3, 4, 5},
+			},
+		},
+		{
+			info: `js{1, 4-5} filename="my file.js" showLineNumbers theme='dark mode'`,
+			expected: &CodeContent{
+				Language:         "js",
+				Filename:         "my file.js",
+				HighlightedLines: []int{1, 4, 5},
+				Attributes:       []CodeAttribute{attr("showLineNumbers", ""), attr("theme", "dark mode")},
+			},
+		},
+		{
+			info: `python hl_lines="2 4-5" {4,x,9-7}`,
+			expected: &CodeContent{
+				Language:         "python",
+				HighlightedLines: []int{2, 4, 5},
+			},
+		},
+		{
+			info: "go {1-200000000} {2,9999-20000}",
+			expected: &CodeContent{
+				Language:         "go",
+				HighlightedLines: []int{2},
+			},
+		},
+		{
+			info: "go {9999-10000}",
+			expected: &CodeContent{
+				Language:         "go",
+				HighlightedLines: []int{9999, 10000},
+			},
+		},
+		{
+			info: "title=example.txt",
+			expected: &CodeContent{
+				Filename: "example.txt",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.info, func(t *testing.T) {
+			cc := ParseCodeInfo(c.info)
+			assert.Equal(t, c.expected, cc)
+
+			// the info string can be read back the same way
+			assert.Equal(t, c.expected, ParseCodeInfo(cc.Info()))
+		})
+	}
+}
+
+func TestCodeInfo(t *testing.T) {
+	cc := &CodeContent{
+		Language:         "go",
+		Filename:         "cmd/main.go",
+		HighlightedLines: []int{1, 3, 4, 5, 8},
+		Attributes: []CodeAttribute{
+			{Type: "codeAttribute", Key: "caption", Value: `Say "hi"`},
+			{Type: "codeAttribute", Key: "wrap"},
+		},
+	}
+	assert.Equal(t, `go title=cmd/main.go {1,3-5,8} caption='Say "hi"' wrap`, cc.Info())
+
+	v, ok := cc.Attribute("caption")
+	assert.True(t, ok)
+	assert.Equal(t, `Say "hi"`, v)
+	_, ok = cc.Attribute("missing")
+	assert.False(t, ok)
+}
+
+func TestMarkdownCodeInfo(t *testing.T) {
+	for _, p := range markdownParsers {
+		t.Run(p.name, func(t *testing.T) {
+			mc := NewMarkdownConverter(WithMarkdownParser(p.parser))
+
+			out, err := mc.ToBlocks("```go title=main.go {2}\npackage main\nfunc main() {}\n```\n\n    indented")
+			assert.NoError(t, err)
+			assert.Equal(t, []Block{
+				{
+					Type: TypeCode,
+					Content: &CodeContent{
+						Language:         "go",
+						Filename:         "main.go",
+						HighlightedLines: []int{2},
+						Code:             "package main\nfunc main() {}",
+					},
+				},
+				{
+					Type: TypeCode,
+					Content: &CodeContent{
+						Indented: true,
+						Code:     "indented",
+					},
+				},
+			}, out)
+
+			assert.Equal(t, "```go title=main.go {2}\npackage main\nfunc main() {}\n```", ToMarkdown(out[:1]))
+		})
+	}
+}
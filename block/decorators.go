@@ -0,0 +1,232 @@
+package block
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// DecoratorSyntax sets the decorators that MarkdownConverter uses for inline syntax that isn't
+// part of Markdown itself. Syntax with an empty decorator name is left as text.
+type DecoratorSyntax struct {
+	// Highlight is the decorator for ==text==.
+	Highlight string
+	// Underline is the decorator for ++text++.
+	Underline string
+	// Superscript is the decorator for ^text^. The text can't contain spaces.
+	Superscript string
+	// Subscript is the decorator for ~text~. The text can't contain spaces.
+	Subscript string
+}
+
+// DefaultDecoratorSyntax uses the decorator names that the HTML renderer knows about.
+var DefaultDecoratorSyntax = DecoratorSyntax{
+	Highlight:   "highlight",
+	Underline:   "underline",
+	Superscript: "sup",
+	Subscript:   "sub",
+}
+
+// WithDecoratorSyntax turns on the inline syntax for extra decorators. It nests with emphasis,
+// links and other inline Markdown, but only within the same element: ==a **b== c** is left as
+// text.
+func WithDecoratorSyntax(ds DecoratorSyntax) MarkdownOption {
+	return markdownOptionFn(func(mc *MarkdownConverter) {
+		mc.decoratorSyntax = &ds
+	})
+}
+
+func (ds *DecoratorSyntax) decorator(delim string) string {
+	switch delim {
+	case "==":
+		return ds.Highlight
+	case "++":
+		return ds.Underline
+	case "^":
+		return ds.Superscript
+	case "~":
+		return ds.Subscript
+	}
+	return ""
+}
+
+// decoratorItem is a piece of the inline content of a node: either text, a delimiter, or a node
+// that isn't text.
+type decoratorItem struct {
+	text  string
+	delim string
+	node  *blackfriday.Node
+
+	// for delimiters, the index of the matching delimiter, or -1 if it's unmatched
+	pair int
+}
+
+// apply finds pairs of delimiters in the text of the document, and wraps the
+// nodes between them in new container nodes. It returns the decorator name for each new node.
+func (ds *DecoratorSyntax) apply(root *blackfriday.Node) map[*blackfriday.Node]string {
+	var parents []*blackfriday.Node
+	root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && node.Type == blackfriday.Text && node.Parent != nil {
+			if len(parents) == 0 || parents[len(parents)-1] != node.Parent {
+				parents = append(parents, node.Parent)
+			}
+		}
+		return blackfriday.GoToNext
+	})
+
+	decorators := make(map[*blackfriday.Node]string)
+	for _, parent := range parents {
+		ds.applyToChildren(parent, decorators)
+	}
+	return decorators
+}
+
+func (ds *DecoratorSyntax) applyToChildren(parent *blackfriday.Node, decorators map[*blackfriday.Node]string) {
+	var items []decoratorItem
+	for child := parent.FirstChild; child != nil; child = child.Next {
+		if child.Type == blackfriday.Text {
+			items = append(items, ds.split(string(child.Literal))...)
+		} else {
+			items = append(items, decoratorItem{node: child, pair: -1})
+		}
+	}
+	if !matchDelimiters(items) {
+		return
+	}
+
+	for parent.FirstChild != nil {
+		parent.FirstChild.Unlink()
+	}
+
+	stack := []*blackfriday.Node{parent}
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			node := blackfriday.NewNode(blackfriday.Text)
+			node.Literal = []byte(text.String())
+			stack[len(stack)-1].AppendChild(node)
+			text.Reset()
+		}
+	}
+
+	for i, item := range items {
+		switch {
+		case item.node != nil:
+			flush()
+			stack[len(stack)-1].AppendChild(item.node)
+		case item.delim == "" || item.pair < 0:
+			text.WriteString(item.text)
+		case item.pair > i:
+			flush()
+			node := blackfriday.NewNode(blackfriday.Emph)
+			decorators[node] = ds.decorator(item.delim)
+			stack[len(stack)-1].AppendChild(node)
+			stack = append(stack, node)
+		default:
+			flush()
+			stack = stack[:len(stack)-1]
+		}
+	}
+	flush()
+}
+
+// split breaks text into runs of text and the delimiters of enabled decorators.
+func (ds *DecoratorSyntax) split(s string) []decoratorItem {
+	var items []decoratorItem
+	start := 0
+	for i := 0; i < len(s); {
+		delim := ""
+		switch {
+		case strings.HasPrefix(s[i:], "=="), strings.HasPrefix(s[i:], "++"):
+			delim = s[i : i+2]
+		case s[i] == '^':
+			delim = "^"
+		case s[i] == '~' && (i == 0 || s[i-1] != '~') && (i+1 == len(s) || s[i+1] != '~'):
+			delim = "~"
+		}
+		if delim == "" || ds.decorator(delim) == "" {
+			i++
+			continue
+		}
+
+		if i > start {
+			items = append(items, decoratorItem{text: s[start:i], pair: -1})
+		}
+		items = append(items, decoratorItem{text: delim, delim: delim, pair: -1})
+		i += len(delim)
+		start = i
+	}
+	if start < len(s) {
+		items = append(items, decoratorItem{text: s[start:], pair: -1})
+	}
+	return items
+}
+
+// matchDelimiters pairs up opening and closing delimiters, and reports whether any were paired.
+// A delimiter can open if it's followed by something other than a space, and close if it's
+// preceded by something other than a space. Unmatched openers inside a pair are left as text.
+func matchDelimiters(items []decoratorItem) bool {
+	var openers []int
+	matched := false
+
+	for i := range items {
+		item := &items[i]
+		if item.delim == "" {
+			continue
+		}
+
+		if i > 0 && !endsWithSpace(items[i-1]) {
+			opener := -1
+			for j := len(openers) - 1; j >= 0; j-- {
+				if items[openers[j]].delim == item.delim {
+					opener = j
+					break
+				}
+			}
+			if opener >= 0 && validContent(items, openers[opener], i) {
+				items[openers[opener]].pair = i
+				item.pair = openers[opener]
+				openers = openers[:opener]
+				matched = true
+				continue
+			}
+		}
+
+		if i+1 < len(items) && !startsWithSpace(items[i+1]) && items[i+1].delim != item.delim {
+			openers = append(openers, i)
+		}
+	}
+	return matched
+}
+
+// validContent checks the items between two delimiters. Superscripts and subscripts can only
+// contain text without spaces.
+func validContent(items []decoratorItem, open, close int) bool {
+	if len(items[open].delim) > 1 {
+		return true
+	}
+	for _, item := range items[open+1 : close] {
+		if item.node != nil || item.delim != "" || strings.IndexFunc(item.text, unicode.IsSpace) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func startsWithSpace(item decoratorItem) bool {
+	if item.node != nil {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(item.text)
+	return unicode.IsSpace(r)
+}
+
+func endsWithSpace(item decoratorItem) bool {
+	if item.node != nil {
+		return false
+	}
+	r, _ := utf8.DecodeLastRuneInString(item.text)
+	return unicode.IsSpace(r)
+}
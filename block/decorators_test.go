@@ -0,0 +1,94 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownDecoratorSyntax(t *testing.T) {
+	span := func(text string, marks ...string) Block {
+		return Block{Type: "span", Content: &SpanContent{Text: text, Marks: marks}}
+	}
+
+	cases := []struct {
+		name     string
+		input    string
+		children []Block
+	}{
+		{
+			name:  "all decorators",
+			input: "==marked== ++under++ x^2^ H~2~O",
+			children: []Block{
+				span("marked", "highlight"),
+				span(" "),
+				span("under", "underline"),
+				span(" x"),
+				span("2", "sup"),
+				span(" H"),
+				span("2", "sub"),
+				span("O"),
+			},
+		},
+		{
+			name:  "nested with emphasis",
+			input: "==a **bold** [link](https://example.com)== and **b ++c++**",
+			children: []Block{
+				span("a ", "highlight"),
+				span("bold", "strong", "highlight"),
+				span(" ", "highlight"),
+				span("link", "mark1", "highlight"),
+				span(" and "),
+				span("b ", "strong"),
+				span("c", "underline", "strong"),
+			},
+		},
+		{
+			name:  "unmatched and spaced delimiters",
+			input: "a == b == c, ~5 and ~6, x^a b^ and ~~gone~~ ==open",
+			children: []Block{
+				span("a == b == c, ~5 and ~6, x^a b^ and "),
+				span("gone", "strike-through"),
+				span(" ==open"),
+			},
+		},
+		{
+			name:     "crossing other marks",
+			input:    "==a **b== c**",
+			children: []Block{span("==a "), span("b== c", "strong")},
+		},
+		{
+			name:     "code spans",
+			input:    "`==not==` ==yes==",
+			children: []Block{span("==not==", "code"), span(" "), span("yes", "highlight")},
+		},
+	}
+
+	mc := NewMarkdownConverter(WithDecoratorSyntax(DefaultDecoratorSyntax))
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, err := mc.ToBlocks(c.input)
+			assert.NoError(t, err)
+			assert.Len(t, out, 1)
+			assert.Equal(t, c.children, out[0].Content.(*BlockContent).Children)
+		})
+	}
+}
+
+func TestMarkdownDecoratorSyntaxNames(t *testing.T) {
+	mc := NewMarkdownConverter(WithDecoratorSyntax(DecoratorSyntax{Highlight: "mark"}))
+
+	out, err := mc.ToBlocks("==a== ++b++")
+	assert.NoError(t, err)
+	assert.Equal(t, []Block{
+		{Type: "span", Content: &SpanContent{Text: "a", Marks: []string{"mark"}}},
+		{Type: "span", Content: &SpanContent{Text: " ++b++"}},
+	}, out[0].Content.(*BlockContent).Children)
+
+	out, err = NewMarkdownConverter().ToBlocks("==a==")
+	assert.NoError(t, err)
+	assert.Equal(t, []Block{
+		{Type: "span", Content: &SpanContent{Text: "==a=="}},
+	}, out[0].Content.(*BlockContent).Children)
+}
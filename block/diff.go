@@ -0,0 +1,263 @@
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mjm/mpsanity/patch"
+)
+
+type DiffOption interface {
+	Apply(d *blockDiffer)
+}
+
+type diffOptionFn func(d *blockDiffer)
+
+func (fn diffOptionFn) Apply(d *blockDiffer) {
+	fn(d)
+}
+
+// WithKeptTypes protects existing blocks of the given types from being removed or overwritten
+// when they have no counterpart in the new blocks. They stay where they are.
+func WithKeptTypes(types ...string) DiffOption {
+	kept := make(map[string]bool)
+	for _, t := range types {
+		kept[t] = true
+	}
+	return WithKept(func(b Block) bool {
+		return kept[b.Type]
+	})
+}
+
+// WithKept is like WithKeptTypes, but protects the existing blocks that fn returns true for, such
+// as photos that weren't part of the content the new blocks came from.
+func WithKept(fn func(b Block) bool) DiffOption {
+	return diffOptionFn(func(d *blockDiffer) {
+		d.kept = append(d.kept, fn)
+	})
+}
+
+// Diff compares the blocks currently stored at path in a document with a new list of blocks and
+// returns the patches that turn one into the other, leaving blocks that didn't change alone.
+//
+// Blocks are matched by _key when the new block has one, and otherwise by their content, ignoring
+// the keys of the blocks and their spans. Between matched blocks, changed blocks of the same type
+// are overwritten in place, keeping their existing key. Any other old blocks are unset and new
+// blocks are inserted next to their closest matched neighbor. Old blocks are addressed by their
+// _key, so they should be read back from the stored document.
+//
+// The patches contain more than one insert, so they need to be committed in separate mutations
+// using patch.Split.
+func Diff(path string, from, to []Block, opts ...DiffOption) []patch.Patch {
+	d := &blockDiffer{
+		path: path,
+		from: from,
+		to:   to,
+	}
+	for _, opt := range opts {
+		opt.Apply(d)
+	}
+	return d.diff()
+}
+
+type blockDiffer struct {
+	path string
+	from []Block
+	to   []Block
+	kept []func(b Block) bool
+
+	fromPrints []string
+	toPrints   []string
+}
+
+func (d *blockDiffer) diff() []patch.Patch {
+	d.fromPrints = fingerprints(d.from)
+	d.toPrints = fingerprints(d.to)
+
+	var ps []patch.Patch
+
+	// the old block each new block ends up stored in, or -1 if it needs to be inserted
+	targets := make([]int, len(d.to))
+	used := make([]bool, len(d.from))
+
+	matches := append(d.matches(), [2]int{len(d.from), len(d.to)})
+	lastFrom, lastTo := 0, 0
+	for _, m := range matches {
+		// pair up the unmatched blocks in the gap before this match in order
+		next := lastFrom
+		for j := lastTo; j < m[1]; j++ {
+			targets[j] = -1
+			if d.to[j].Key != "" {
+				continue
+			}
+			for i := next; i < m[0]; i++ {
+				if d.from[i].Type == d.to[j].Type && !d.isKept(d.from[i]) {
+					targets[j] = i
+					used[i] = true
+					next = i + 1
+					break
+				}
+			}
+		}
+
+		if m[0] < len(d.from) {
+			targets[m[1]] = m[0]
+			used[m[0]] = true
+		}
+		lastFrom, lastTo = m[0]+1, m[1]+1
+	}
+
+	for j, i := range targets {
+		if i == -1 || d.fromPrints[i] == d.toPrints[j] && d.fromPrints[i] != "" {
+			continue
+		}
+		b := d.to[j]
+		b.Key = d.from[i].Key
+		ps = append(ps, patch.Set(d.itemPath(i), b))
+	}
+
+	var unset []string
+	for i, b := range d.from {
+		if !used[i] && !d.isKept(b) {
+			unset = append(unset, d.itemPath(i))
+		}
+	}
+	if len(unset) > 0 {
+		ps = append(ps, patch.Unset(unset...))
+	}
+
+	for j := 0; j < len(d.to); {
+		if targets[j] != -1 {
+			j++
+			continue
+		}
+
+		end := j
+		var items []interface{}
+		for ; end < len(d.to) && targets[end] == -1; end++ {
+			items = append(items, d.to[end])
+		}
+
+		switch {
+		case j > 0:
+			ps = append(ps, patch.InsertAfter(d.itemPath(targets[j-1]), items...))
+		case end < len(d.to):
+			ps = append(ps, patch.InsertBefore(d.itemPath(targets[end]), items...))
+		default:
+			ps = append(ps, d.insertFirst(used, items)...)
+		}
+		j = end
+	}
+
+	return ps
+}
+
+func (d *blockDiffer) isKept(b Block) bool {
+	for _, fn := range d.kept {
+		if fn(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// insertFirst adds blocks when none of the new blocks line up with an old one.
+func (d *blockDiffer) insertFirst(used []bool, items []interface{}) []patch.Patch {
+	for i, b := range d.from {
+		if used[i] || d.isKept(b) {
+			return []patch.Patch{patch.InsertBefore(d.itemPath(i), items...)}
+		}
+	}
+
+	return []patch.Patch{
+		patch.SetIfMissing(d.path, []Block{}),
+		patch.InsertAfter(d.path+"[-1]", items...),
+	}
+}
+
+// matches finds the longest sequence of old and new blocks that line up, as pairs of indexes.
+func (d *blockDiffer) matches() [][2]int {
+	n, m := len(d.from), len(d.to)
+	lengths := make([][]int, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case d.same(i, j):
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var out [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case d.same(i, j):
+			out = append(out, [2]int{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return out
+}
+
+func (d *blockDiffer) same(i, j int) bool {
+	if key := d.to[j].Key; key != "" {
+		return d.from[i].Key == key
+	}
+	return d.fromPrints[i] != "" && d.fromPrints[i] == d.toPrints[j]
+}
+
+func (d *blockDiffer) itemPath(i int) string {
+	if key := d.from[i].Key; key != "" {
+		return fmt.Sprintf("%s[_key==%q]", d.path, key)
+	}
+	return fmt.Sprintf("%s[%d]", d.path, i)
+}
+
+// fingerprints encodes each block in a canonical form that ignores the keys Sanity adds to blocks
+// and spans. Mark definition keys are kept since spans refer to them.
+func fingerprints(blocks []Block) []string {
+	prints := make([]string, len(blocks))
+	for i, b := range blocks {
+		data, err := json.Marshal(b)
+		if err != nil {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			continue
+		}
+		stripKeys(v, false)
+		if data, err = json.Marshal(v); err == nil {
+			prints[i] = string(data)
+		}
+	}
+	return prints
+}
+
+func stripKeys(v interface{}, keepKey bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if !keepKey {
+			delete(v, "_key")
+		}
+		for k, child := range v {
+			stripKeys(child, k == "markDefs")
+		}
+	case []interface{}:
+		for _, child := range v {
+			stripKeys(child, keepKey)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package block
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mjm/mpsanity/patch"
+)
+
+func TestDiff(t *testing.T) {
+	para := func(key, text string) Block {
+		b := New("normal", Text(text))
+		b.Key = key
+		if key != "" {
+			// stored spans have keys too, and they shouldn't affect matching
+			b.Content.(*BlockContent).Children[0].Key = key + "-span"
+		}
+		return b
+	}
+	// blocks written by a patch don't have span keys until they're committed
+	changed := func(key, text string) Block {
+		b := New("normal", Text(text))
+		b.Key = key
+		return b
+	}
+	photo := func(key, asset string) Block {
+		return Block{
+			Type:    TypeMainImage,
+			Key:     key,
+			Content: &ImageContent{Alt: "Photo", Asset: Reference(asset)},
+		}
+	}
+
+	cases := []struct {
+		name     string
+		from     []Block
+		to       []Block
+		opts     []DiffOption
+		expected []Block
+		patches  int
+	}{
+		{
+			name:     "unchanged",
+			from:     []Block{para("a", "One"), para("b", "Two")},
+			to:       []Block{para("", "One"), para("", "Two")},
+			expected: []Block{para("a", "One"), para("b", "Two")},
+		},
+		{
+			name:     "changed block keeps its key",
+			from:     []Block{para("a", "One"), para("b", "Two"), para("c", "Three")},
+			to:       []Block{para("", "One"), para("", "Two!"), para("", "Three")},
+			expected: []Block{para("a", "One"), changed("b", "Two!"), para("c", "Three")},
+			patches:  1,
+		},
+		{
+			name:     "removed block",
+			from:     []Block{para("a", "One"), para("b", "Two"), para("c", "Three")},
+			to:       []Block{para("", "One"), para("", "Three")},
+			expected: []Block{para("a", "One"), para("c", "Three")},
+			patches:  1,
+		},
+		{
+			name:     "inserted blocks",
+			from:     []Block{para("a", "One"), para("b", "Two")},
+			to:       []Block{para("", "Zero"), para("", "One"), para("", "One and a half"), para("", "Two")},
+			expected: []Block{para("", "Zero"), para("a", "One"), para("", "One and a half"), para("b", "Two")},
+			patches:  2,
+		},
+		{
+			name:     "different type is replaced",
+			from:     []Block{para("a", "One"), photo("b", "image-1"), para("c", "Three")},
+			to:       []Block{para("", "One"), para("", "Two"), para("", "Three")},
+			expected: []Block{para("a", "One"), changed("", "Two"), para("c", "Three")},
+			patches:  2,
+		},
+		{
+			name:     "kept photos stay in place",
+			from:     []Block{para("a", "One"), para("b", "Two"), photo("p", "image-1")},
+			to:       []Block{para("", "One"), para("", "Two"), para("", "Three")},
+			opts:     []DiffOption{WithKeptTypes(TypeMainImage)},
+			expected: []Block{para("a", "One"), para("b", "Two"), para("", "Three"), photo("p", "image-1")},
+			patches:  1,
+		},
+		{
+			name:     "kept photos with all text replaced",
+			from:     []Block{para("a", "One"), photo("p", "image-1")},
+			to:       []Block{photo("", "image-2")},
+			opts:     []DiffOption{WithKeptTypes(TypeMainImage)},
+			expected: []Block{photo("", "image-2"), photo("p", "image-1")},
+			patches:  2,
+		},
+		{
+			name: "only chosen blocks are kept",
+			from: []Block{para("a", "One"), photo("b", "image-1"), photo("p", "image-2")},
+			to:   []Block{para("", "One"), photo("", "image-3")},
+			opts: []DiffOption{WithKept(func(b Block) bool {
+				return b.Key == "p"
+			})},
+			expected: []Block{para("a", "One"), photo("b", "image-3"), photo("p", "image-2")},
+			patches:  1,
+		},
+		{
+			name:     "matched by key",
+			from:     []Block{para("a", "One"), para("b", "Two")},
+			to:       []Block{changed("b", "Deux"), para("", "Three")},
+			expected: []Block{changed("b", "Deux"), para("", "Three")},
+			patches:  3,
+		},
+		{
+			name:     "empty body",
+			to:       []Block{para("", "One")},
+			expected: []Block{para("", "One")},
+			patches:  2,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ps := Diff("body", c.from, c.to, c.opts...)
+			assert.Len(t, ps, c.patches)
+
+			doc := map[string]interface{}{}
+			if c.from != nil {
+				doc["body"] = toGeneric(t, c.from)
+			}
+			for _, group := range patch.Split(ps...) {
+				var p patch.Description
+				for _, patcher := range group {
+					patcher.Apply(&p)
+				}
+				if !assert.NoError(t, p.ApplyTo(doc)) {
+					return
+				}
+			}
+
+			assert.Equal(t, toGeneric(t, c.expected), doc["body"])
+		})
+	}
+}
+
+func toGeneric(t *testing.T, blocks []Block) interface{} {
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
@@ -0,0 +1,228 @@
+package block
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	TypeVimeo     = "vimeo"
+	TypeInstagram = "instagram"
+	TypeMastodon  = "mastodon"
+	TypeGist      = "gist"
+	TypeCodePen   = "codepen"
+	TypeSpotify   = "spotify"
+	TypeBluesky   = "bluesky"
+)
+
+// EmbedContent is the content of a block for embedded content from another site.
+type EmbedContent struct {
+	URL string `json:"url"`
+	ID  string `json:"id,omitempty"`
+}
+
+func (c *EmbedContent) PlainText() string {
+	return c.URL
+}
+
+// EmbedMatcher recognizes URLs of embeddable content. It returns the content for the embed
+// block, with a normalized URL and the ID of the content on its site.
+type EmbedMatcher func(u *url.URL) (*EmbedContent, bool)
+
+// MatchEmbedURL creates an EmbedMatcher from a regular expression. The pattern is matched
+// against the whole URL without its scheme, and with any "www.", "m." or "mobile." removed from
+// the start of the host, e.g. "youtube.com/watch?v=abc". The normalized URL is built by
+// expanding the pattern's groups in template, and the group named "id" is the content's ID.
+func MatchEmbedURL(pattern string, template string) EmbedMatcher {
+	re := regexp.MustCompile(pattern)
+	idGroup := -1
+	for i, name := range re.SubexpNames() {
+		if name == "id" {
+			idGroup = i
+		}
+	}
+
+	return func(u *url.URL) (*EmbedContent, bool) {
+		s := embedMatchString(u)
+		m := re.FindStringSubmatchIndex(s)
+		if m == nil {
+			return nil, false
+		}
+
+		content := &EmbedContent{
+			URL: string(re.ExpandString(nil, template, s, m)),
+		}
+		if idGroup > 0 && m[2*idGroup] >= 0 {
+			content.ID = s[m[2*idGroup]:m[2*idGroup+1]]
+		}
+		return content, true
+	}
+}
+
+func embedMatchString(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	for _, prefix := range []string{"www.", "m.", "mobile."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+
+	s := host + u.EscapedPath()
+	if u.RawQuery != "" {
+		s += "?" + u.RawQuery
+	}
+	return s
+}
+
+// matchEmbed finds the first matcher that recognizes a URL.
+func matchEmbed(rawurl string, matchers []EmbedMatcher) (*EmbedContent, bool) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, false
+	}
+	if u.Scheme == "" && strings.HasPrefix(rawurl, "//") {
+		u.Scheme = "https"
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, false
+	}
+
+	for _, m := range matchers {
+		if content, ok := m(u); ok {
+			return content, true
+		}
+	}
+	return nil, false
+}
+
+var (
+	TwitterMatchers = []EmbedMatcher{
+		MatchEmbedURL(`^(?:twitter|x)\.com/(?P<user>\w+)/status(?:es)?/(?P<id>\d+)/?(?:\?.*)?$`,
+			"https://twitter.com/${user}/status/${id}"),
+		MatchEmbedURL(`^platform\.twitter\.com/embed/Tweet\.html\?(?:.*&)?id=(?P<id>\d+)`,
+			"https://twitter.com/i/status/${id}"),
+	}
+	YouTubeMatchers = []EmbedMatcher{
+		MatchEmbedURL(`^youtube\.com/watch\?(?:.*&)?v=(?P<id>[\w-]+)`,
+			"https://www.youtube.com/watch?v=${id}"),
+		MatchEmbedURL(`^(?:youtu\.be|youtube\.com/shorts|youtube\.com/live|youtube(?:-nocookie)?\.com/embed)/(?P<id>[\w-]+)/?(?:\?.*)?$`,
+			"https://www.youtube.com/watch?v=${id}"),
+	}
+	VimeoMatchers = []EmbedMatcher{
+		MatchEmbedURL(`^(?:vimeo\.com|player\.vimeo\.com/video)/(?P<id>\d+)/?(?:\?.*)?$`,
+			"https://vimeo.com/${id}"),
+	}
+	InstagramMatchers = []EmbedMatcher{
+		MatchEmbedURL(`^instagram\.com/(?P<kind>p|reel|tv)/(?P<id>[\w-]+)(?:/embed)?/?(?:\?.*)?$`,
+			"https://www.instagram.com/${kind}/${id}/"),
+	}
+	MastodonMatchers = []EmbedMatcher{
+		MatchEmbedURL(`^(?P<host>[\w.-]+)/@(?P<user>\w+)/(?P<id>\d+)(?:/embed)?/?(?:\?.*)?$`,
+			"https://${host}/@${user}/${id}"),
+		MatchEmbedURL(`^(?P<host>[\w.-]+)/users/(?P<user>\w+)/statuses/(?P<id>\d+)/?(?:\?.*)?$`,
+			"https://${host}/@${user}/${id}"),
+	}
+	GistMatchers = []EmbedMatcher{
+		MatchEmbedURL(`^gist\.github\.com/(?P<user>[\w-]+)/(?P<id>[0-9a-f]+)(?:\.js)?/?(?:[?#].*)?$`,
+			"https://gist.github.com/${user}/${id}"),
+	}
+	CodePenMatchers = []EmbedMatcher{
+		MatchEmbedURL(`^codepen\.io/(?P<user>[\w-]+)/(?:pen|full|details|embed)/(?P<id>\w+)/?(?:\?.*)?$`,
+			"https://codepen.io/${user}/pen/${id}"),
+	}
+	SpotifyMatchers = []EmbedMatcher{
+		MatchEmbedURL(`^open\.spotify\.com/(?:embed/|intl-[\w-]+/)?(?P<kind>track|album|playlist|episode|show|artist)/(?P<id>\w+)/?(?:\?.*)?$`,
+			"https://open.spotify.com/${kind}/${id}"),
+	}
+	BlueskyMatchers = []EmbedMatcher{
+		MatchEmbedURL(`^bsky\.app/profile/(?P<user>[\w.:-]+)/post/(?P<id>\w+)/?(?:\?.*)?$`,
+			"https://bsky.app/profile/${user}/post/${id}"),
+	}
+)
+
+var (
+	TweetMarkdownRule     = EmbedMarkdownRule(TypeTweet, TwitterMatchers...)
+	YouTubeMarkdownRule   = EmbedMarkdownRule(TypeYouTube, YouTubeMatchers...)
+	VimeoMarkdownRule     = EmbedMarkdownRule(TypeVimeo, VimeoMatchers...)
+	InstagramMarkdownRule = EmbedMarkdownRule(TypeInstagram, InstagramMatchers...)
+	MastodonMarkdownRule  = EmbedMarkdownRule(TypeMastodon, MastodonMatchers...)
+	GistMarkdownRule      = EmbedMarkdownRule(TypeGist, GistMatchers...)
+	CodePenMarkdownRule   = EmbedMarkdownRule(TypeCodePen, CodePenMatchers...)
+	SpotifyMarkdownRule   = EmbedMarkdownRule(TypeSpotify, SpotifyMatchers...)
+	BlueskyMarkdownRule   = EmbedMarkdownRule(TypeBluesky, BlueskyMatchers...)
+)
+
+// iframeEmbeds are the embeds that HTMLConverter recognizes from the src of an iframe.
+var iframeEmbeds = []struct {
+	typeName string
+	matchers []EmbedMatcher
+}{
+	{TypeTweet, TwitterMatchers},
+	{TypeYouTube, YouTubeMatchers},
+	{TypeVimeo, VimeoMatchers},
+	{TypeInstagram, InstagramMatchers},
+	{TypeCodePen, CodePenMatchers},
+	{TypeSpotify, SpotifyMatchers},
+}
+
+// EmbedMarkdownRule creates a rule that turns a paragraph containing only a link into a block
+// of the given type, if one of the matchers recognizes the link's URL.
+func EmbedMarkdownRule(typeName string, matchers ...EmbedMatcher) MarkdownRuleFunc {
+	return func(b *Builder, node *blackfriday.Node, entering bool) (blackfriday.WalkStatus, bool) {
+		if !entering || node.Type != blackfriday.Paragraph {
+			return blackfriday.GoToNext, false
+		}
+
+		child := node.FirstChild
+		if child != nil && child.Type == blackfriday.Text && len(child.Literal) == 0 {
+			child = child.Next
+		}
+		if child == nil || child.Type != blackfriday.Link || child.Next != nil {
+			return blackfriday.GoToNext, false
+		}
+
+		content, ok := matchEmbed(string(child.Destination), matchers)
+		if !ok {
+			return blackfriday.GoToNext, false
+		}
+
+		b.AddCustomBlock(typeName, content)
+		return blackfriday.SkipChildren, true
+	}
+}
+
+// EmbedHTMLRule is the HTMLConverter equivalent of EmbedMarkdownRule, for paragraphs
+// containing only a link.
+func EmbedHTMLRule(typeName string, matchers ...EmbedMatcher) HTMLRuleFunc {
+	return func(b *Builder, node *html.Node, entering bool) (blackfriday.WalkStatus, bool) {
+		if !entering || node.DataAtom != atom.P {
+			return blackfriday.GoToNext, false
+		}
+
+		var link *html.Node
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch {
+			case child.Type == html.TextNode && strings.TrimSpace(child.Data) == "":
+				continue
+			case child.DataAtom == atom.A && link == nil:
+				link = child
+			default:
+				return blackfriday.GoToNext, false
+			}
+		}
+		if link == nil {
+			return blackfriday.GoToNext, false
+		}
+
+		content, ok := matchEmbed(attr(link, "href"), matchers)
+		if !ok {
+			return blackfriday.GoToNext, false
+		}
+
+		b.AddCustomBlock(typeName, content)
+		return blackfriday.SkipChildren, true
+	}
+}
@@ -0,0 +1,187 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchEmbed(t *testing.T) {
+	cases := []struct {
+		name     string
+		url      string
+		matchers []EmbedMatcher
+		content  *EmbedContent
+	}{
+		{
+			name:     "tweet",
+			url:      "https://twitter.com/some_user/status/1234567890?s=20",
+			matchers: TwitterMatchers,
+			content:  &EmbedContent{URL: "https://twitter.com/some_user/status/1234567890", ID: "1234567890"},
+		},
+		{
+			name:     "tweet on x.com",
+			url:      "https://x.com/some_user/status/1234567890",
+			matchers: TwitterMatchers,
+			content:  &EmbedContent{URL: "https://twitter.com/some_user/status/1234567890", ID: "1234567890"},
+		},
+		{
+			name:     "mobile tweet",
+			url:      "https://mobile.twitter.com/some_user/status/1234567890",
+			matchers: TwitterMatchers,
+			content:  &EmbedContent{URL: "https://twitter.com/some_user/status/1234567890", ID: "1234567890"},
+		},
+		{
+			name:     "youtu.be",
+			url:      "https://youtu.be/TamwFUUd9Yk?t=42",
+			matchers: YouTubeMatchers,
+			content:  &EmbedContent{URL: "https://www.youtube.com/watch?v=TamwFUUd9Yk", ID: "TamwFUUd9Yk"},
+		},
+		{
+			name:     "mobile youtube",
+			url:      "https://m.youtube.com/watch?feature=share&v=TamwFUUd9Yk",
+			matchers: YouTubeMatchers,
+			content:  &EmbedContent{URL: "https://www.youtube.com/watch?v=TamwFUUd9Yk", ID: "TamwFUUd9Yk"},
+		},
+		{
+			name:     "youtube shorts",
+			url:      "https://youtube.com/shorts/TamwFUUd9Yk",
+			matchers: YouTubeMatchers,
+			content:  &EmbedContent{URL: "https://www.youtube.com/watch?v=TamwFUUd9Yk", ID: "TamwFUUd9Yk"},
+		},
+		{
+			name:     "vimeo",
+			url:      "https://player.vimeo.com/video/76979871?h=8272103f6e",
+			matchers: VimeoMatchers,
+			content:  &EmbedContent{URL: "https://vimeo.com/76979871", ID: "76979871"},
+		},
+		{
+			name:     "instagram",
+			url:      "https://www.instagram.com/p/CbA1x2yJk3L/?utm_source=ig_web_copy_link",
+			matchers: InstagramMatchers,
+			content:  &EmbedContent{URL: "https://www.instagram.com/p/CbA1x2yJk3L/", ID: "CbA1x2yJk3L"},
+		},
+		{
+			name:     "mastodon",
+			url:      "https://mastodon.social/@Gargron/109393836290736453",
+			matchers: MastodonMatchers,
+			content:  &EmbedContent{URL: "https://mastodon.social/@Gargron/109393836290736453", ID: "109393836290736453"},
+		},
+		{
+			name:     "mastodon activitypub URL",
+			url:      "https://hachyderm.io/users/someone/statuses/109393836290736453",
+			matchers: MastodonMatchers,
+			content:  &EmbedContent{URL: "https://hachyderm.io/@someone/109393836290736453", ID: "109393836290736453"},
+		},
+		{
+			name:     "gist",
+			url:      "https://gist.github.com/mjm/5f1c2a0b9e3d4c7a8b6e#file-main-go",
+			matchers: GistMatchers,
+			content:  &EmbedContent{URL: "https://gist.github.com/mjm/5f1c2a0b9e3d4c7a8b6e", ID: "5f1c2a0b9e3d4c7a8b6e"},
+		},
+		{
+			name:     "codepen",
+			url:      "https://codepen.io/someone/full/abcXYZ",
+			matchers: CodePenMatchers,
+			content:  &EmbedContent{URL: "https://codepen.io/someone/pen/abcXYZ", ID: "abcXYZ"},
+		},
+		{
+			name:     "spotify",
+			url:      "https://open.spotify.com/intl-de/track/4uLU6hMCjMI75M1A2tKUQC?si=abc",
+			matchers: SpotifyMatchers,
+			content:  &EmbedContent{URL: "https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC", ID: "4uLU6hMCjMI75M1A2tKUQC"},
+		},
+		{
+			name:     "bluesky",
+			url:      "https://bsky.app/profile/someone.bsky.social/post/3k4duaz5vfs2b",
+			matchers: BlueskyMatchers,
+			content:  &EmbedContent{URL: "https://bsky.app/profile/someone.bsky.social/post/3k4duaz5vfs2b", ID: "3k4duaz5vfs2b"},
+		},
+		{
+			name:     "profile is not a tweet",
+			url:      "https://twitter.com/some_user",
+			matchers: TwitterMatchers,
+		},
+		{
+			name:     "other site is not a video",
+			url:      "https://example.com/watch?v=TamwFUUd9Yk",
+			matchers: YouTubeMatchers,
+		},
+		{
+			name:     "not a web URL",
+			url:      "mailto:someone@vimeo.com",
+			matchers: VimeoMatchers,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			content, ok := matchEmbed(c.url, c.matchers)
+			assert.Equal(t, c.content != nil, ok)
+			assert.Equal(t, c.content, content)
+		})
+	}
+}
+
+func TestEmbedMarkdownRule(t *testing.T) {
+	mc := NewMarkdownConverter(WithMarkdownRules(VimeoMarkdownRule, BlueskyMarkdownRule))
+
+	out, err := mc.ToBlocks(`https://vimeo.com/76979871
+
+Not an embed: https://vimeo.com/76979871`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Block{
+		{
+			Type:    TypeVimeo,
+			Content: &EmbedContent{URL: "https://vimeo.com/76979871", ID: "76979871"},
+		},
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{
+						Type: "span",
+						Content: &SpanContent{
+							Text: "Not an embed: ",
+						},
+					},
+					{
+						Type: "span",
+						Content: &SpanContent{
+							Text:  "https://vimeo.com/76979871",
+							Marks: []string{"mark1"},
+						},
+					},
+				},
+				MarkDefs: []MarkDef{
+					{
+						Type: "link",
+						Key:  "mark1",
+						Data: &LinkData{Href: "https://vimeo.com/76979871"},
+					},
+				},
+			},
+		},
+	}, out)
+}
+
+func TestEmbedHTMLRule(t *testing.T) {
+	hc := NewHTMLConverter(WithHTMLRules(EmbedHTMLRule(TypeMastodon, MastodonMatchers...)))
+
+	out, err := hc.ToBlocks(`<p><a href="https://mastodon.social/@Gargron/109393836290736453">https://mastodon.social/@Gargron/109393836290736453</a></p>
+<iframe src="https://open.spotify.com/embed/album/1DFixLWuPkv3KT3TnV35m3"></iframe>`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Block{
+		{
+			Type:    TypeMastodon,
+			Content: &EmbedContent{URL: "https://mastodon.social/@Gargron/109393836290736453", ID: "109393836290736453"},
+		},
+		{
+			Type:    TypeSpotify,
+			Content: &EmbedContent{URL: "https://open.spotify.com/album/1DFixLWuPkv3KT3TnV35m3", ID: "1DFixLWuPkv3KT3TnV35m3"},
+		},
+	}, out)
+}
@@ -0,0 +1,62 @@
+package block
+
+import (
+	"strconv"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+const MarkFootnote = "footnote"
+
+// FootnoteData is the mark definition for a footnote reference. The span it annotates holds the
+// footnote number, and the footnote's content is kept in the mark definition.
+type FootnoteData struct {
+	Number int     `json:"number"`
+	Text   []Block `json:"text"`
+}
+
+func (w *markdownWalker) addFootnote(node *blackfriday.Node) blackfriday.WalkStatus {
+	var text []Block
+	if node.Footnote != nil {
+		var err error
+		text, err = w.footnoteBlocks(node.Footnote)
+		if err != nil {
+			w.err = err
+			return blackfriday.Terminate
+		}
+	}
+
+	key := w.b.AddMarkDef(MarkFootnote, &FootnoteData{
+		Number: node.NoteID,
+		Text:   text,
+	})
+	w.b.StartMark(key)
+	w.b.AppendText(strconv.Itoa(node.NoteID))
+	w.b.EndMark(key)
+
+	return blackfriday.SkipChildren
+}
+
+// footnoteBlocks converts the content of a footnote into its own list of blocks.
+func (w *markdownWalker) footnoteBlocks(item *blackfriday.Node) ([]Block, error) {
+	sub := &markdownWalker{
+		mc:         w.mc,
+		ctx:        w.ctx,
+		b:          &Builder{StrictBlocks: w.mc.strictBlocks},
+		listStarts: w.listStarts,
+	}
+
+	// short footnotes have inline content directly inside the item
+	sub.b.StartBlock("normal")
+	for child := item.FirstChild; child != nil; child = child.Next {
+		if err := sub.walk(child); err != nil {
+			return nil, err
+		}
+	}
+
+	bs := sub.b.Blocks()
+	if err := sub.b.Err(); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
@@ -0,0 +1,753 @@
+package block
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLConverter converts HTML to blocks. It produces the same blocks that MarkdownConverter
+// does for equivalent Markdown.
+type HTMLConverter struct {
+	rules         []HTMLRuleFunc
+	markdownRules []MarkdownRuleFunc
+	imageResolver ImageResolver
+	linkResolver  LinkResolver
+
+	strictBlocks bool
+}
+
+func NewHTMLConverter(opts ...HTMLConverterOption) *HTMLConverter {
+	hc := &HTMLConverter{}
+	for _, o := range opts {
+		o.Apply(hc)
+	}
+	return hc
+}
+
+// HTMLRuleFunc is the HTML equivalent of MarkdownRuleFunc. Rules see each node before the
+// built-in handling, and can take over a node by returning true. Like with blackfriday's Walk,
+// returning SkipChildren when entering a node skips the end of it too.
+type HTMLRuleFunc func(b *Builder, node *html.Node, entering bool) (blackfriday.WalkStatus, bool)
+
+type HTMLConverterOption interface {
+	Apply(hc *HTMLConverter)
+}
+
+type htmlConverterOptionFn func(hc *HTMLConverter)
+
+func (f htmlConverterOptionFn) Apply(hc *HTMLConverter) {
+	f(hc)
+}
+
+func WithHTMLRules(rules ...HTMLRuleFunc) HTMLConverterOption {
+	return htmlConverterOptionFn(func(hc *HTMLConverter) {
+		hc.rules = append(hc.rules, rules...)
+	})
+}
+
+// WithHTMLStrictBlocks is the HTMLConverter equivalent of WithStrictBlocks.
+func WithHTMLStrictBlocks() HTMLConverterOption {
+	return htmlConverterOptionFn(func(hc *HTMLConverter) {
+		hc.strictBlocks = true
+	})
+}
+
+// WithHTMLMarkdownRules runs Markdown rules on HTML too, so the same rules can be shared by both
+// converters. The rules see the blackfriday nodes that the equivalent Markdown would have, such as
+// a Paragraph for a p element or a Link for an a element. Elements that Markdown has nothing like
+// aren't passed to them.
+func WithHTMLMarkdownRules(rules ...MarkdownRuleFunc) HTMLConverterOption {
+	return htmlConverterOptionFn(func(hc *HTMLConverter) {
+		hc.markdownRules = append(hc.markdownRules, rules...)
+	})
+}
+
+// WithHTMLImageResolver sets how images are turned into image blocks. Without a resolver,
+// images are replaced by their alt text.
+func WithHTMLImageResolver(r ImageResolver) HTMLConverterOption {
+	return htmlConverterOptionFn(func(hc *HTMLConverter) {
+		hc.imageResolver = r
+	})
+}
+
+func (hc *HTMLConverter) ToBlocks(s string) ([]Block, error) {
+	return hc.ToBlocksContext(context.Background(), s)
+}
+
+// ToBlocksContext converts an HTML fragment to blocks, using ctx for any requests needed to
+// resolve images and links.
+func (hc *HTMLConverter) ToBlocksContext(ctx context.Context, s string) ([]Block, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(s), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	w := &htmlWalker{
+		hc:  hc,
+		ctx: ctx,
+		b:   &Builder{StrictBlocks: hc.strictBlocks},
+
+		linkKeys: make(map[*html.Node]string),
+	}
+	if len(hc.markdownRules) > 0 {
+		w.markdownNodes = markdownNodes(nodes)
+	}
+	for _, node := range nodes {
+		if w.walk(node) == blackfriday.Terminate {
+			break
+		}
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	bs := w.b.Blocks()
+	if err := w.b.Err(); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+// htmlWalker holds the state for converting a single HTML fragment.
+type htmlWalker struct {
+	hc  *HTMLConverter
+	ctx context.Context
+	b   *Builder
+
+	// how many blockquotes we're inside
+	quote int
+	// whether a list item has started, but nothing has been added to its block yet
+	itemOpen bool
+	// whether any text has been added to the current block
+	hasText bool
+	// whether leading whitespace in the next text should be dropped, because we're at the start
+	// of a line or the last text ended with whitespace
+	skipSpace bool
+
+	// the nodes that Markdown rules see for each HTML node
+	markdownNodes map[*html.Node]*blackfriday.Node
+
+	// the mark keys of links that have been started, which rules may have taken over
+	linkKeys map[*html.Node]string
+	err      error
+}
+
+// walk visits a node and its children in the same order blackfriday would.
+func (w *htmlWalker) walk(node *html.Node) blackfriday.WalkStatus {
+	switch w.visit(node, true) {
+	case blackfriday.Terminate:
+		return blackfriday.Terminate
+	case blackfriday.SkipChildren:
+		return blackfriday.GoToNext
+	}
+
+	if node.Type != html.ElementNode {
+		return blackfriday.GoToNext
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if w.walk(child) == blackfriday.Terminate {
+			return blackfriday.Terminate
+		}
+	}
+
+	return w.visit(node, false)
+}
+
+func (w *htmlWalker) visit(node *html.Node, entering bool) blackfriday.WalkStatus {
+	hc, b := w.hc, w.b
+
+	for _, rule := range hc.rules {
+		if result, ok := rule(b, node, entering); ok {
+			return result
+		}
+	}
+	// like in Markdown, rules only see the end of nodes that can have children
+	if md, ok := w.markdownNodes[node]; ok && node.Type == html.ElementNode && (entering || !markdownLeaf(md)) {
+		for _, rule := range hc.markdownRules {
+			if result, ok := rule(b, md, entering); ok {
+				return result
+			}
+		}
+	}
+
+	switch node.Type {
+	case html.TextNode:
+		w.appendText(node.Data, w.markdownNodes[node])
+		return blackfriday.GoToNext
+	case html.ElementNode:
+		break
+	default:
+		return blackfriday.SkipChildren
+	}
+
+	switch node.DataAtom {
+	case atom.P:
+		if entering {
+			w.startTextBlock(w.paragraphStyle())
+		} else {
+			w.endBlock()
+		}
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		if entering {
+			w.startTextBlock(node.Data)
+		} else {
+			w.endBlock()
+		}
+	case atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main, atom.Aside,
+		atom.Figure, atom.Figcaption:
+		if w.hasText {
+			w.endBlock()
+		}
+
+		// the caption of a figure is already part of its image block
+		if entering && node.DataAtom == atom.Figcaption && hc.imageResolver != nil && figureImage(node) {
+			return blackfriday.SkipChildren
+		}
+	case atom.Br:
+		if entering {
+			w.ensureBlock()
+			w.trimTrailingSpace()
+			b.AppendText("\n")
+			w.skipSpace = true
+		}
+	case atom.Em, atom.I:
+		w.mark("em", entering)
+	case atom.Strong, atom.B:
+		w.mark("strong", entering)
+	case atom.S, atom.Del, atom.Strike:
+		w.mark("strike-through", entering)
+	case atom.Code:
+		w.mark("code", entering)
+	case atom.A:
+		if entering {
+			w.ensureBlock()
+			href := attr(node, "href")
+			if href == "" {
+				// without an href, it's just a placeholder or anchor and not a link
+				break
+			}
+			key := addLink(w.ctx, b, hc.linkResolver, href)
+			w.linkKeys[node] = key
+			b.StartMark(key)
+		} else if key, ok := w.linkKeys[node]; ok {
+			delete(w.linkKeys, node)
+			b.EndMark(key)
+		}
+	case atom.Ul, atom.Ol:
+		if entering {
+			w.endBlock()
+			if node.DataAtom == atom.Ol {
+				start, err := strconv.Atoi(attr(node, "start"))
+				if err != nil {
+					start = 1
+				}
+				b.StartNumberedList(start)
+			} else {
+				b.StartList("bullet")
+			}
+		} else {
+			b.EndList()
+		}
+	case atom.Li:
+		if entering {
+			w.trimTrailingSpace()
+			b.StartListItem()
+			if bc := b.currentText(); bc != nil {
+				bc.Style = w.paragraphStyle()
+			}
+			w.itemOpen = true
+			w.hasText = false
+			w.skipSpace = true
+		} else {
+			w.endBlock()
+		}
+	case atom.Blockquote:
+		if !entering {
+			w.endBlock()
+			w.quote--
+			break
+		}
+
+		w.endBlock()
+		if tweet, ok := embeddedTweet(node); ok {
+			b.AddCustomBlock(TypeTweet, tweet)
+			return blackfriday.SkipChildren
+		}
+		w.quote++
+	case atom.Pre:
+		if !entering {
+			break
+		}
+
+		w.endBlock()
+		b.AddCustomBlock(TypeCode, &CodeContent{
+			Language: codeLanguage(node),
+			Code:     strings.TrimSuffix(textContent(node), "\n"),
+		})
+		return blackfriday.SkipChildren
+	case atom.Hr:
+		if entering {
+			w.endBlock()
+			b.AddCustomBlock(TypeBreak, &BreakContent{
+				Style: "lineBreak",
+			})
+		}
+	case atom.Table:
+		if entering {
+			w.endBlock()
+			b.AddCustomBlock(TypeTable, tableFromHTML(node))
+		}
+		return blackfriday.SkipChildren
+	case atom.Img:
+		if entering {
+			return w.addImage(node)
+		}
+	case atom.Iframe:
+		if !entering {
+			break
+		}
+
+		src := attr(node, "src")
+		for _, embed := range iframeEmbeds {
+			if content, ok := matchEmbed(src, embed.matchers); ok {
+				w.endBlock()
+				b.AddCustomBlock(embed.typeName, content)
+				break
+			}
+		}
+		return blackfriday.SkipChildren
+	case atom.Script, atom.Style, atom.Template, atom.Noscript:
+		return blackfriday.SkipChildren
+	}
+
+	return blackfriday.GoToNext
+}
+
+func (w *htmlWalker) paragraphStyle() string {
+	if w.quote > 0 {
+		return "blockquote"
+	}
+	return "normal"
+}
+
+// startTextBlock starts the block for a paragraph or heading. The first one in a list item
+// becomes the list item's own block, and any after that continue the list item.
+func (w *htmlWalker) startTextBlock(style string) {
+	w.trimTrailingSpace()
+	w.hasText = false
+	w.skipSpace = true
+
+	if w.itemOpen {
+		w.itemOpen = false
+		if bc := w.b.currentText(); bc != nil {
+			bc.Style = style
+			return
+		}
+	}
+
+	w.b.StartListContinuation(style)
+}
+
+func (w *htmlWalker) endBlock() {
+	w.trimTrailingSpace()
+	w.b.EndBlock()
+	w.itemOpen = false
+	w.hasText = false
+	w.skipSpace = true
+}
+
+// ensureBlock starts a paragraph for inline content that isn't inside any block element.
+func (w *htmlWalker) ensureBlock() {
+	if w.b.current == nil {
+		w.startTextBlock(w.paragraphStyle())
+	}
+}
+
+func (w *htmlWalker) mark(mark string, entering bool) {
+	w.ensureBlock()
+	if entering {
+		w.b.StartMark(mark)
+	} else {
+		w.b.EndMark(mark)
+	}
+}
+
+var htmlSpaceRegex = regexp.MustCompile(`[ \t\n\r\f]+`)
+
+// appendText adds text with its whitespace collapsed the way a browser would display it. If the
+// text has a Markdown node, Markdown rules can handle the text instead.
+func (w *htmlWalker) appendText(text string, md *blackfriday.Node) {
+	text = htmlSpaceRegex.ReplaceAllString(text, " ")
+	if text == "" || (text == " " && w.b.current == nil) {
+		return
+	}
+
+	w.ensureBlock()
+	if w.skipSpace {
+		text = strings.TrimPrefix(text, " ")
+	}
+	if text == "" {
+		return
+	}
+
+	if !w.textRule(md, text) {
+		w.b.AppendText(text)
+	}
+	w.itemOpen = false
+	w.hasText = true
+	w.skipSpace = strings.HasSuffix(text, " ")
+}
+
+func (w *htmlWalker) textRule(md *blackfriday.Node, text string) bool {
+	if md == nil {
+		return false
+	}
+
+	md.Literal = []byte(text)
+	for _, rule := range w.hc.markdownRules {
+		if _, ok := rule(w.b, md, true); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// trimTrailingSpace removes whitespace from the end of the current block, which a browser
+// wouldn't display.
+func (w *htmlWalker) trimTrailingSpace() {
+	b := w.b
+	if b.curSpan != nil {
+		sc := b.curSpan.Content.(*SpanContent)
+		sc.Text = strings.TrimRight(sc.Text, " ")
+		if sc.Text != "" {
+			return
+		}
+	}
+
+	if b.current == nil {
+		return
+	}
+	bc, ok := b.current.Content.(*BlockContent)
+	if !ok {
+		return
+	}
+	for i := len(bc.Children) - 1; i >= 0; i-- {
+		sc, ok := bc.Children[i].Content.(*SpanContent)
+		if !ok {
+			return
+		}
+		sc.Text = strings.TrimRight(sc.Text, " ")
+		if sc.Text != "" {
+			return
+		}
+		bc.Children = bc.Children[:i]
+	}
+}
+
+func (w *htmlWalker) addImage(node *html.Node) blackfriday.WalkStatus {
+	alt := attr(node, "alt")
+	if w.hc.imageResolver == nil {
+		w.appendText(alt, nil)
+		return blackfriday.GoToNext
+	}
+
+	ref, err := w.hc.imageResolver.ResolveImage(w.ctx, attr(node, "src"))
+	if err != nil {
+		w.err = err
+		return blackfriday.Terminate
+	}
+
+	caption := attr(node, "title")
+	if c := figureCaption(node); c != "" {
+		caption = c
+	}
+
+	w.b.InsertCustomBlock(TypeMainImage, &ImageContent{
+		Alt:     alt,
+		Caption: caption,
+		Asset:   ref,
+	})
+	// the text on either side of the image ends up in separate blocks, so keep its whitespace
+	w.skipSpace = false
+	return blackfriday.GoToNext
+}
+
+// figureImage checks whether a figure caption belongs to a figure with an image in it.
+func figureImage(caption *html.Node) bool {
+	for n := caption.Parent; n != nil; n = n.Parent {
+		if n.DataAtom != atom.Figure {
+			continue
+		}
+
+		found := false
+		var visit func(n *html.Node)
+		visit = func(n *html.Node) {
+			for child := n.FirstChild; child != nil; child = child.NextSibling {
+				if child.DataAtom == atom.Img {
+					found = true
+				}
+				visit(child)
+			}
+		}
+		visit(n)
+		return found
+	}
+	return false
+}
+
+// figureCaption finds the caption for an image inside a figure element.
+func figureCaption(img *html.Node) string {
+	for n := img.Parent; n != nil; n = n.Parent {
+		if n.DataAtom != atom.Figure {
+			continue
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.DataAtom == atom.Figcaption {
+				return strings.TrimSpace(htmlSpaceRegex.ReplaceAllString(textContent(child), " "))
+			}
+		}
+	}
+	return ""
+}
+
+func attr(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(node *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(node, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// textContent collects all of the text inside a node, keeping its whitespace as is.
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+
+	var s strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		s.WriteString(textContent(child))
+	}
+	return s.String()
+}
+
+// codeLanguage finds the language of a code block from a class like "language-go" on the pre
+// element or the code element inside it.
+func codeLanguage(pre *html.Node) string {
+	nodes := []*html.Node{pre}
+	if code := pre.FirstChild; code != nil && code.DataAtom == atom.Code {
+		nodes = append(nodes, code)
+	}
+
+	for _, n := range nodes {
+		for _, c := range strings.Fields(attr(n, "class")) {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if strings.HasPrefix(c, prefix) {
+					return strings.TrimPrefix(c, prefix)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func tableFromHTML(node *html.Node) *TableContent {
+	tc := &TableContent{}
+
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			switch child.DataAtom {
+			case atom.Tr:
+				tc.Rows = append(tc.Rows, TableRow{
+					Type:  "tableRow",
+					Cells: []string{},
+				})
+				visit(child)
+			case atom.Th, atom.Td:
+				if len(tc.Rows) == 0 {
+					continue
+				}
+				row := &tc.Rows[len(tc.Rows)-1]
+				text := htmlSpaceRegex.ReplaceAllString(textContent(child), " ")
+				row.Cells = append(row.Cells, strings.TrimSpace(text))
+				if child.DataAtom == atom.Th {
+					row.Header = true
+				}
+			case atom.Table:
+				// nested tables can't be represented
+			default:
+				visit(child)
+			}
+		}
+	}
+	visit(node)
+
+	return tc
+}
+
+// embeddedTweet finds the tweet in a blockquote from Twitter's embed code.
+func embeddedTweet(node *html.Node) (*TweetContent, bool) {
+	if !hasClass(node, "twitter-tweet") {
+		return nil, false
+	}
+
+	var tweet *TweetContent
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.DataAtom == atom.A {
+				if content, ok := matchEmbed(attr(child, "href"), TwitterMatchers); ok {
+					tweet = content
+				}
+			}
+			visit(child)
+		}
+	}
+	visit(node)
+
+	return tweet, tweet != nil
+}
+
+// markdownNodes builds the blackfriday tree that the equivalent Markdown would parse to, for
+// running Markdown rules on HTML. Elements without a Markdown equivalent, like div, are left out
+// but their children are kept. Text gets its final literal when the walker reaches it.
+func markdownNodes(nodes []*html.Node) map[*html.Node]*blackfriday.Node {
+	m := make(map[*html.Node]*blackfriday.Node)
+	doc := blackfriday.NewNode(blackfriday.Document)
+	for _, node := range nodes {
+		addMarkdownNode(m, doc, node)
+	}
+	return m
+}
+
+func addMarkdownNode(m map[*html.Node]*blackfriday.Node, parent *blackfriday.Node, node *html.Node) {
+	var md *blackfriday.Node
+	switch node.Type {
+	case html.TextNode:
+		// whitespace around elements isn't part of the text, like in Markdown
+		if strings.TrimSpace(node.Data) == "" && (node.PrevSibling == nil || node.NextSibling == nil) {
+			return
+		}
+		md = blackfriday.NewNode(blackfriday.Text)
+		md.Literal = []byte(htmlSpaceRegex.ReplaceAllString(node.Data, " "))
+	case html.ElementNode:
+		md = markdownElement(node)
+		if md == nil {
+			if skipMarkdownChildren(node) {
+				return
+			}
+			md = parent
+		}
+	default:
+		return
+	}
+
+	if md != parent {
+		parent.AppendChild(md)
+		m[node] = md
+		if md.Type == blackfriday.Code || md.Type == blackfriday.CodeBlock {
+			return
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		addMarkdownNode(m, md, child)
+	}
+}
+
+func markdownElement(node *html.Node) *blackfriday.Node {
+	var md *blackfriday.Node
+	switch node.DataAtom {
+	case atom.P:
+		md = blackfriday.NewNode(blackfriday.Paragraph)
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		md = blackfriday.NewNode(blackfriday.Heading)
+		md.Level = int(node.Data[1] - '0')
+	case atom.Ul, atom.Ol:
+		md = blackfriday.NewNode(blackfriday.List)
+		if node.DataAtom == atom.Ol {
+			md.ListFlags = blackfriday.ListTypeOrdered
+		}
+	case atom.Li:
+		md = blackfriday.NewNode(blackfriday.Item)
+		if node.Parent != nil && node.Parent.DataAtom == atom.Ol {
+			md.ListFlags = blackfriday.ListTypeOrdered
+		}
+	case atom.Blockquote:
+		md = blackfriday.NewNode(blackfriday.BlockQuote)
+	case atom.Pre:
+		md = blackfriday.NewNode(blackfriday.CodeBlock)
+		md.IsFenced = true
+		md.Info = []byte(codeLanguage(node))
+		md.Literal = []byte(textContent(node))
+	case atom.Code:
+		md = blackfriday.NewNode(blackfriday.Code)
+		md.Literal = []byte(textContent(node))
+	case atom.Em, atom.I:
+		md = blackfriday.NewNode(blackfriday.Emph)
+	case atom.Strong, atom.B:
+		md = blackfriday.NewNode(blackfriday.Strong)
+	case atom.S, atom.Del, atom.Strike:
+		md = blackfriday.NewNode(blackfriday.Del)
+	case atom.A:
+		md = blackfriday.NewNode(blackfriday.Link)
+		md.Destination = []byte(attr(node, "href"))
+		md.Title = []byte(attr(node, "title"))
+	case atom.Img:
+		md = blackfriday.NewNode(blackfriday.Image)
+		md.Destination = []byte(attr(node, "src"))
+		md.Title = []byte(attr(node, "title"))
+		if alt := attr(node, "alt"); alt != "" {
+			text := blackfriday.NewNode(blackfriday.Text)
+			text.Literal = []byte(alt)
+			md.AppendChild(text)
+		}
+	case atom.Br:
+		md = blackfriday.NewNode(blackfriday.Hardbreak)
+	case atom.Hr:
+		md = blackfriday.NewNode(blackfriday.HorizontalRule)
+	}
+	return md
+}
+
+// markdownLeaf checks for the nodes that blackfriday only visits once, since they can't have
+// children.
+func markdownLeaf(md *blackfriday.Node) bool {
+	switch md.Type {
+	case blackfriday.Text, blackfriday.Code, blackfriday.CodeBlock, blackfriday.Hardbreak,
+		blackfriday.HorizontalRule:
+		return true
+	}
+	return false
+}
+
+// skipMarkdownChildren lists the elements whose content Markdown rules shouldn't see, since the
+// converter doesn't treat it as text.
+func skipMarkdownChildren(node *html.Node) bool {
+	switch node.DataAtom {
+	case atom.Table, atom.Iframe, atom.Script, atom.Style, atom.Template, atom.Noscript:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,327 @@
+package block
+
+import (
+	"context"
+	"testing"
+
+	"github.com/russross/blackfriday/v2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestHTMLToBlocksMatchesMarkdown(t *testing.T) {
+	cases := []struct {
+		name     string
+		html     string
+		markdown string
+	}{
+		{
+			name:     "paragraphs with formatting",
+			html:     "<p>This is <em>some text</em> with\n  <b>formatting</b>. Including <code>code.</code></p>\n<p>And <i>another</i> paragraph.</p>",
+			markdown: "This is _some text_ with **formatting**. Including `code.`\n\nAnd _another_ paragraph.",
+		},
+		{
+			name:     "text outside of paragraphs",
+			html:     "Some <strong>bold</strong> text",
+			markdown: "Some **bold** text",
+		},
+		{
+			name:     "line breaks",
+			html:     "<p>Line one<br>\n  line two</p>",
+			markdown: "Line one  \nline two",
+		},
+		{
+			name:     "headings",
+			html:     "<h1>Heading 1</h1>\n<p>Some text.</p>\n<h3>Heading 3</h3>",
+			markdown: "# Heading 1\n\nSome text.\n\n### Heading 3",
+		},
+		{
+			name:     "nested lists",
+			html:     "<ul>\n  <li>One</li>\n  <li>Two\n    <ol><li>Nested</li><li>Nested again</li></ol>\n  </li>\n  <li>Three</li>\n</ul>\n<p>After.</p>",
+			markdown: "* One\n* Two\n    1. Nested\n    2. Nested again\n* Three\n\nAfter.",
+		},
+		{
+			name:     "list items with paragraphs",
+			html:     "<ol><li><p>One</p>\n<p>Continued</p></li><li><p>Two</p></li></ol>",
+			markdown: "1. One\n\n    Continued\n2. Two",
+		},
+		{
+			name:     "blockquotes",
+			html:     "<blockquote>\n  <p>A quote.</p>\n  <p>Second paragraph.</p>\n</blockquote>",
+			markdown: "> A quote.\n>\n> Second paragraph.",
+		},
+		{
+			name:     "links",
+			html:     `<p>A <a href="https://example.com/foo">link with <strong>bold</strong></a> and <a href="/bar">another</a>.</p>`,
+			markdown: "A [link with **bold**](https://example.com/foo) and [another](/bar).",
+		},
+		{
+			name:     "code blocks",
+			html:     "<pre><code class=\"language-go\">func main() {\n\tif a &lt; b {}\n}\n</code></pre>",
+			markdown: "```go\nfunc main() {\n\tif a < b {}\n}\n```",
+		},
+		{
+			name:     "strikethrough and rules",
+			html:     "<p>Some <del>old</del> text.</p><hr><p>After</p>",
+			markdown: "Some ~~old~~ text.\n\n---\n\nAfter",
+		},
+		{
+			name:     "tables",
+			html:     "<table><thead><tr><th>Name</th><th>Value</th></tr></thead><tbody><tr><td><em>foo</em></td><td>1</td></tr></tbody></table>",
+			markdown: "| Name | Value |\n| --- | --- |\n| *foo* | 1 |",
+		},
+		{
+			name:     "images without a resolver",
+			html:     `<p>An <img src="https://example.com/a.png" alt="image"> here</p>`,
+			markdown: "An ![image](https://example.com/a.png) here",
+		},
+		{
+			name:     "lists in blockquotes",
+			html:     "<blockquote><p>Quoted list:</p>\n<ul><li>One</li><li>Two</li></ul>\nAfter the list</blockquote>",
+			markdown: "> Quoted list:\n>\n> * One\n> * Two\n>\n> After the list",
+		},
+		{
+			name:     "nested content in list items",
+			html:     "<ol><li><h2>A heading</h2><pre><code>x := 1</code></pre><p>After the code</p><blockquote>Quoted</blockquote></li>\n<li>Two</li></ol>",
+			markdown: "1. ## A heading\n\n    ```\n    x := 1\n    ```\n\n    After the code\n\n    > Quoted\n2. Two",
+		},
+		{
+			name:     "text after a nested list",
+			html:     "<ul><li>One<ul><li>Nested</li></ul>Back in one</li><li>Two</li></ul>",
+			markdown: "* One\n    * Nested\n\n    Back in one\n* Two",
+		},
+	}
+
+	mc := NewMarkdownConverter()
+	hc := NewHTMLConverter()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			expected, err := mc.ToBlocks(c.markdown)
+			assert.NoError(t, err)
+
+			out, err := hc.ToBlocks(c.html)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, out)
+		})
+	}
+}
+
+func TestHTMLEmbeds(t *testing.T) {
+	hc := NewHTMLConverter()
+
+	out, err := hc.ToBlocks(`<p>Watch this:</p>
+<iframe width="560" height="315" src="https://www.youtube-nocookie.com/embed/TamwFUUd9Yk?start=10" frameborder="0" allowfullscreen></iframe>
+<blockquote class="twitter-tweet"><p lang="en">Some tweet</p>&mdash; Someone (@some_user) <a href="https://twitter.com/some_user/status/1234567890?ref_src=twsrc%5Etfw">April 1, 2020</a></blockquote>
+<script async src="https://platform.twitter.com/widgets.js" charset="utf-8"></script>
+<iframe src="https://platform.twitter.com/embed/Tweet.html?id=987654321"></iframe>`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Block{
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{
+						Type: "span",
+						Content: &SpanContent{
+							Text: "Watch this:",
+						},
+					},
+				},
+				MarkDefs: []MarkDef{},
+			},
+		},
+		{
+			Type: TypeYouTube,
+			Content: &YouTubeContent{
+				URL: "https://www.youtube.com/watch?v=TamwFUUd9Yk",
+				ID:  "TamwFUUd9Yk",
+			},
+		},
+		{
+			Type: TypeTweet,
+			Content: &TweetContent{
+				URL: "https://twitter.com/some_user/status/1234567890",
+				ID:  "1234567890",
+			},
+		},
+		{
+			Type: TypeTweet,
+			Content: &TweetContent{
+				URL: "https://twitter.com/i/status/987654321",
+				ID:  "987654321",
+			},
+		},
+	}, out)
+}
+
+func TestHTMLImages(t *testing.T) {
+	resolver := ImageResolverFunc(func(ctx context.Context, src string) (Reference, error) {
+		return Reference("image-" + src[len(src)-3:]), nil
+	})
+	mc := NewMarkdownConverter(WithImageResolver(resolver))
+	hc := NewHTMLConverter(WithHTMLImageResolver(resolver))
+
+	expected, err := mc.ToBlocks(`Before **the ![An image](https://example.com/abc "A caption") after**.
+
+![Just an image](https://example.com/def "Figure caption")`)
+	assert.NoError(t, err)
+
+	out, err := hc.ToBlocks(`<p>Before <strong>the <img src="https://example.com/abc" alt="An image" title="A caption"> after</strong>.</p>
+<figure>
+  <img src="https://example.com/def" alt="Just an image">
+  <figcaption>Figure
+    caption</figcaption>
+</figure>`)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+}
+
+func TestHTMLRules(t *testing.T) {
+	highlightRule := func(b *Builder, node *html.Node, entering bool) (blackfriday.WalkStatus, bool) {
+		if node.Type != html.ElementNode || node.Data != "mark" {
+			return blackfriday.GoToNext, false
+		}
+
+		if entering {
+			b.StartMark("highlight")
+		} else {
+			b.EndMark("highlight")
+		}
+		return blackfriday.GoToNext, true
+	}
+	hc := NewHTMLConverter(WithHTMLRules(highlightRule))
+
+	out, err := hc.ToBlocks("<p>Some <mark>highlighted</mark> text</p>")
+	assert.NoError(t, err)
+	assert.Equal(t, []Block{
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{
+						Type: "span",
+						Content: &SpanContent{
+							Text: "Some ",
+						},
+					},
+					{
+						Type: "span",
+						Content: &SpanContent{
+							Text:  "highlighted",
+							Marks: []string{"highlight"},
+						},
+					},
+					{
+						Type: "span",
+						Content: &SpanContent{
+							Text: " text",
+						},
+					},
+				},
+				MarkDefs: []MarkDef{},
+			},
+		},
+	}, out)
+}
+
+func TestHTMLMarkdownRules(t *testing.T) {
+	rules := []MarkdownRuleFunc{TweetMarkdownRule, YouTubeMarkdownRule, MentionMarkdownRule}
+	mc := NewMarkdownConverter(WithMarkdownRules(rules...))
+	hc := NewHTMLConverter(WithHTMLMarkdownRules(rules...))
+
+	expected, err := mc.ToBlocks(`Hi @someone@example.social, see [@other@example.com](https://example.com) and ` + "`@code@example.com`" + `.
+
+https://www.youtube.com/watch?v=TamwFUUd9Yk
+
+- A list item for @third@example.net`)
+	assert.NoError(t, err)
+
+	out, err := hc.ToBlocks(`<p>Hi   @someone@example.social, see <a href="https://example.com">@other@example.com</a> and <code>@code@example.com</code>.</p>
+<div>
+  <p>
+    <a href="https://www.youtube.com/watch?v=TamwFUUd9Yk">https://www.youtube.com/watch?v=TamwFUUd9Yk</a>
+  </p>
+</div>
+<ul><li>A list item for @third@example.net</li></ul>`)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+}
+
+func TestHTMLLinkWithoutHref(t *testing.T) {
+	hc := NewHTMLConverter()
+
+	out, err := hc.ToBlocks(`<p>An <a name="anchor">anchor</a> and <a href="">an empty link</a></p>`)
+	assert.NoError(t, err)
+	assert.Equal(t, []Block{
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Content: &SpanContent{Text: "An anchor and an empty link"}},
+				},
+				MarkDefs: []MarkDef{},
+			},
+		},
+	}, out)
+}
+
+func TestHTMLRuleTakesOverLinkStart(t *testing.T) {
+	// the rule only handles the start of links, so the end reaches the built-in handling
+	linkRule := func(b *Builder, node *html.Node, entering bool) (blackfriday.WalkStatus, bool) {
+		return blackfriday.GoToNext, entering && node.Type == html.ElementNode && node.Data == "a"
+	}
+	hc := NewHTMLConverter(WithHTMLRules(linkRule))
+
+	var out []Block
+	var err error
+	assert.NotPanics(t, func() {
+		out, err = hc.ToBlocks(`<p>A <a href="https://example.com">link</a> here</p>`)
+	})
+	assert.NoError(t, err)
+	assert.Len(t, out, 1)
+	assert.Equal(t, "A link here", ToPlainText(out))
+
+	mdRule := func(b *Builder, node *blackfriday.Node, entering bool) (blackfriday.WalkStatus, bool) {
+		return blackfriday.GoToNext, entering && node.Type == blackfriday.Link
+	}
+	mc := NewMarkdownConverter(WithMarkdownRules(mdRule))
+	out, err = mc.ToBlocks("A [link](https://example.com) here")
+	assert.NoError(t, err)
+	assert.Len(t, out, 1)
+	assert.Equal(t, "A link here", ToPlainText(out))
+}
+
+func TestHTMLRulesSkipChildren(t *testing.T) {
+	// like blackfriday's Walk, skipping the children of a node also skips the end of it
+	var mdVisits []bool
+	mdRule := func(b *Builder, node *blackfriday.Node, entering bool) (blackfriday.WalkStatus, bool) {
+		if node.Type != blackfriday.Emph {
+			return blackfriday.GoToNext, false
+		}
+		mdVisits = append(mdVisits, entering)
+		return blackfriday.SkipChildren, true
+	}
+	mdOut, err := NewMarkdownConverter(WithMarkdownRules(mdRule)).ToBlocks("Some *skipped* text")
+	assert.NoError(t, err)
+
+	var htmlVisits []bool
+	htmlRule := func(b *Builder, node *html.Node, entering bool) (blackfriday.WalkStatus, bool) {
+		if node.Type != html.ElementNode || node.Data != "em" {
+			return blackfriday.GoToNext, false
+		}
+		htmlVisits = append(htmlVisits, entering)
+		return blackfriday.SkipChildren, true
+	}
+	htmlOut, err := NewHTMLConverter(WithHTMLRules(htmlRule)).ToBlocks("<p>Some <em>skipped</em> text</p>")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []bool{true}, mdVisits)
+	assert.Equal(t, mdVisits, htmlVisits)
+	assert.Equal(t, "Some  text", ToPlainText(mdOut))
+	assert.Equal(t, "Some text", ToPlainText(htmlOut))
+}
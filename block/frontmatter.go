@@ -0,0 +1,208 @@
+package block
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// FrontMatter is metadata from the start of a Markdown document.
+type FrontMatter map[string]interface{}
+
+// FrontMatterKeys are the keys that YAML front matter is recognized by if no others are given.
+var FrontMatterKeys = []string{
+	"title", "slug", "date", "published", "summary", "description", "tags", "categories",
+	"draft", "author",
+}
+
+type FrontMatterOption interface {
+	Apply(fs *frontMatterSplitter)
+}
+
+type frontMatterOptionFn func(fs *frontMatterSplitter)
+
+func (fn frontMatterOptionFn) Apply(fs *frontMatterSplitter) {
+	fn(fs)
+}
+
+// WithFrontMatterKeys adds keys that YAML front matter can start with.
+func WithFrontMatterKeys(keys ...string) FrontMatterOption {
+	return frontMatterOptionFn(func(fs *frontMatterSplitter) {
+		fs.keys = append(fs.keys, keys...)
+	})
+}
+
+type frontMatterSplitter struct {
+	keys []string
+}
+
+// SplitFrontMatter separates the front matter at the start of a Markdown document from the rest
+// of it. Front matter is either YAML between lines of "---", or TOML between lines of "+++". If
+// the document doesn't start with front matter, it returns nil and the whole document. Since
+// "---" is also a thematic break, YAML only counts as front matter if its first line sets one of
+// the known keys, and otherwise the text is left alone. Once the front matter is recognized,
+// errors parsing it are returned for either format.
+func SplitFrontMatter(s string, opts ...FrontMatterOption) (FrontMatter, string, error) {
+	fs := &frontMatterSplitter{keys: FrontMatterKeys}
+	for _, opt := range opts {
+		opt.Apply(fs)
+	}
+
+	var delim string
+	switch {
+	case strings.HasPrefix(s, "---"):
+		delim = "---"
+	case strings.HasPrefix(s, "+++"):
+		delim = "+++"
+	default:
+		return nil, s, nil
+	}
+
+	r := bufio.NewReader(strings.NewReader(s))
+	first, _ := r.ReadString('\n')
+	if strings.TrimRight(first, " \t\r\n") != delim {
+		return nil, s, nil
+	}
+
+	var meta strings.Builder
+	offset := len(first)
+	for {
+		line, err := r.ReadString('\n')
+		offset += len(line)
+		if strings.TrimRight(line, " \t\r\n") == delim {
+			break
+		}
+		if err != nil {
+			// without a closing delimiter, it's not front matter after all
+			return nil, s, nil
+		}
+		meta.WriteString(line)
+	}
+
+	var fm FrontMatter
+	var err error
+	if delim == "+++" {
+		fm, err = parseTOMLFrontMatter(meta.String())
+	} else if fs.startsWithKey(meta.String()) {
+		fm, err = parseYAMLFrontMatter(meta.String())
+	} else {
+		return nil, s, nil
+	}
+	if err != nil {
+		return nil, s, err
+	}
+	return fm, strings.TrimLeft(s[offset:], "\r\n"), nil
+}
+
+// startsWithKey reports whether the first line of s sets one of the known keys.
+func (fs *frontMatterSplitter) startsWithKey(s string) bool {
+	line := strings.TrimLeft(s, "\r\n")
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	i := strings.IndexByte(line, ':')
+	if i < 0 {
+		return false
+	}
+	key := line[:i]
+	for _, k := range fs.keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTOMLFrontMatter(s string) (FrontMatter, error) {
+	fm := make(FrontMatter)
+	if _, err := toml.Decode(s, (*map[string]interface{})(&fm)); err != nil {
+		return nil, fmt.Errorf("invalid TOML front matter: %w", err)
+	}
+	return fm, nil
+}
+
+func parseYAMLFrontMatter(s string) (FrontMatter, error) {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return nil, fmt.Errorf("invalid YAML front matter: %w", err)
+	}
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("invalid YAML front matter: not a mapping")
+	}
+	return FrontMatter(yamlValue(m).(map[string]interface{})), nil
+}
+
+// yamlValue converts the maps in a decoded YAML value to have string keys, like JSON and TOML.
+func yamlValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = yamlValue(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = yamlValue(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// String returns the value of the first of keys that is set to a string.
+func (fm FrontMatter) String(keys ...string) string {
+	for _, k := range keys {
+		if s, ok := fm[k].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// Strings returns the value of the first of keys that is set to a list of strings. A single
+// string is treated as a list of one.
+func (fm FrontMatter) Strings(keys ...string) []string {
+	for _, k := range keys {
+		switch v := fm[k].(type) {
+		case string:
+			return []string{v}
+		case []string:
+			return v
+		case []interface{}:
+			var ss []string
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					ss = append(ss, s)
+				}
+			}
+			return ss
+		}
+	}
+	return nil
+}
+
+// Time returns the value of the first of keys that is set to a date or time. Besides native
+// YAML and TOML dates, strings in RFC 3339 or YYYY-MM-DD format are accepted.
+func (fm FrontMatter) Time(keys ...string) (time.Time, bool) {
+	for _, k := range keys {
+		switch v := fm[k].(type) {
+		case time.Time:
+			return v, true
+		case string:
+			for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+				if t, err := time.Parse(layout, v); err == nil {
+					return t, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,129 @@
+package block
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		fm    FrontMatter
+		body  string
+	}{
+		{
+			name:  "no front matter",
+			input: "# Title\n\nSome text.",
+			body:  "# Title\n\nSome text.",
+		},
+		{
+			name: "YAML",
+			input: `---
+title: My Post
+date: 2020-05-01T10:00:00Z
+tags: [go, sanity]
+extra:
+  nested: true
+---
+
+Some text.`,
+			fm: FrontMatter{
+				"title": "My Post",
+				"date":  "2020-05-01T10:00:00Z",
+				"tags":  []interface{}{"go", "sanity"},
+				"extra": map[string]interface{}{"nested": true},
+			},
+			body: "Some text.",
+		},
+		{
+			name: "TOML",
+			input: `+++
+title = "My Post"
+slug = "my-post"
+date = 2020-05-01T10:00:00Z
+tags = ["go"]
++++
+Some text.`,
+			fm: FrontMatter{
+				"title": "My Post",
+				"slug":  "my-post",
+				"date":  time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC),
+				"tags":  []interface{}{"go"},
+			},
+			body: "Some text.",
+		},
+		{
+			name:  "horizontal rule",
+			input: "---\n\nSome text.",
+			body:  "---\n\nSome text.",
+		},
+		{
+			name:  "between thematic breaks",
+			input: "---\nSome *text*.\n\n---\nMore text.",
+			body:  "---\nSome *text*.\n\n---\nMore text.",
+		},
+		{
+			name:  "list between thematic breaks",
+			input: "---\n- one\n- two\n---\n",
+			body:  "---\n- one\n- two\n---\n",
+		},
+		{
+			name:  "colon between thematic breaks",
+			input: "---\nNote: something\n---\nMore text.",
+			body:  "---\nNote: something\n---\nMore text.",
+		},
+		{
+			name:  "not at start",
+			input: "Some text.\n\n---\ntitle: nope\n---\n",
+			body:  "Some text.\n\n---\ntitle: nope\n---\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fm, body, err := SplitFrontMatter(c.input)
+			assert.NoError(t, err)
+			assert.Equal(t, c.fm, fm)
+			assert.Equal(t, c.body, body)
+		})
+	}
+}
+
+func TestSplitFrontMatterInvalid(t *testing.T) {
+	_, _, err := SplitFrontMatter("+++\ntitle = \n+++\nSome text.")
+	assert.Error(t, err)
+
+	_, _, err = SplitFrontMatter("---\ntitle: [unclosed\n---\nSome text.")
+	assert.Error(t, err)
+}
+
+func TestSplitFrontMatterKeys(t *testing.T) {
+	fm, body, err := SplitFrontMatter("---\nlayout: post\n---\nSome text.", WithFrontMatterKeys("layout"))
+	assert.NoError(t, err)
+	assert.Equal(t, FrontMatter{"layout": "post"}, fm)
+	assert.Equal(t, "Some text.", body)
+}
+
+func TestFrontMatterValues(t *testing.T) {
+	fm := FrontMatter{
+		"title":      "My Post",
+		"date":       "2020-05-01",
+		"categories": "go",
+		"tags":       []interface{}{"go", 1, "sanity"},
+	}
+
+	assert.Equal(t, "My Post", fm.String("name", "title"))
+	assert.Equal(t, "", fm.String("summary"))
+	assert.Equal(t, []string{"go", "sanity"}, fm.Strings("tags"))
+	assert.Equal(t, []string{"go"}, fm.Strings("keywords", "categories"))
+
+	date, ok := fm.Time("date")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC), date)
+
+	_, ok = fm.Time("title")
+	assert.False(t, ok)
+}
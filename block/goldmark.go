@@ -0,0 +1,308 @@
+package block
+
+import (
+	"bytes"
+
+	"github.com/russross/blackfriday/v2"
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// GoldmarkParser parses CommonMark using goldmark, with the same extensions that are used with
+// blackfriday: tables, strikethrough, autolinks and footnotes. goldmark's syntax tree is
+// translated into blackfriday nodes, with the start numbers of ordered lists kept in the
+// MarkdownTree.
+var GoldmarkParser MarkdownParser = &goldmarkParser{
+	md: goldmark.New(goldmark.WithExtensions(
+		extension.Table,
+		extension.Strikethrough,
+		extension.Linkify,
+		extension.Footnote)),
+}
+
+type goldmarkParser struct {
+	md goldmark.Markdown
+}
+
+func (p *goldmarkParser) Parse(source []byte) *MarkdownTree {
+	doc := p.md.Parser().Parse(text.NewReader(source))
+
+	c := &goldmarkConverter{
+		source:     source,
+		footnotes:  make(map[int]*east.Footnote),
+		items:      make(map[int]*blackfriday.Node),
+		listStarts: make(map[*blackfriday.Node]int),
+	}
+	root := c.convert(doc)
+
+	// footnotes come after the references to them, so link them up once everything is converted
+	for _, link := range c.footnoteLinks {
+		if fn, ok := c.footnotes[link.NoteID]; ok {
+			link.Destination = fn.Ref
+			link.Footnote = c.items[link.NoteID]
+		}
+	}
+	return &MarkdownTree{Root: root, ListStarts: c.listStarts}
+}
+
+// goldmarkConverter translates a goldmark AST into the equivalent blackfriday nodes.
+type goldmarkConverter struct {
+	source []byte
+
+	footnotes     map[int]*east.Footnote
+	items         map[int]*blackfriday.Node
+	footnoteLinks []*blackfriday.Node
+	listStarts    map[*blackfriday.Node]int
+}
+
+// convert returns the node for n, or nil if blackfriday has nothing like it. The children of
+// nodes without an equivalent are added to the parent instead, so their text isn't lost.
+func (c *goldmarkConverter) convert(n gast.Node) *blackfriday.Node {
+	switch n := n.(type) {
+	case *gast.Document:
+		return c.container(blackfriday.Document, n)
+	case *gast.Paragraph, *gast.TextBlock:
+		// blackfriday uses paragraphs for the text of tight list items too
+		return c.container(blackfriday.Paragraph, n)
+	case *gast.Heading:
+		node := c.container(blackfriday.Heading, n)
+		node.Level = n.Level
+		return node
+	case *gast.ThematicBreak:
+		return blackfriday.NewNode(blackfriday.HorizontalRule)
+	case *gast.CodeBlock:
+		node := blackfriday.NewNode(blackfriday.CodeBlock)
+		node.Literal = c.lines(n)
+		return node
+	case *gast.FencedCodeBlock:
+		node := blackfriday.NewNode(blackfriday.CodeBlock)
+		node.IsFenced = true
+		if n.Info != nil {
+			node.Info = n.Info.Segment.Value(c.source)
+		}
+		node.Literal = c.lines(n)
+		return node
+	case *gast.Blockquote:
+		return c.container(blackfriday.BlockQuote, n)
+	case *gast.List:
+		node := c.container(blackfriday.List, n)
+		node.Tight = n.IsTight
+		node.BulletChar = n.Marker
+		if n.IsOrdered() {
+			node.ListFlags |= blackfriday.ListTypeOrdered
+			node.Delimiter = n.Marker
+			if n.Start != 1 {
+				c.listStarts[node] = n.Start
+			}
+		}
+		for item := node.FirstChild; item != nil; item = item.Next {
+			item.ListData = node.ListData
+		}
+		return node
+	case *gast.ListItem:
+		return c.container(blackfriday.Item, n)
+	case *gast.HTMLBlock:
+		node := blackfriday.NewNode(blackfriday.HTMLBlock)
+		node.Literal = c.lines(n)
+		if n.HasClosure() {
+			node.Literal = append(node.Literal, n.ClosureLine.Value(c.source)...)
+		}
+		return node
+	case *east.Table:
+		return c.table(n)
+	case *east.TableCell:
+		node := c.container(blackfriday.TableCell, n)
+		switch n.Alignment {
+		case east.AlignLeft:
+			node.Align = blackfriday.TableAlignmentLeft
+		case east.AlignRight:
+			node.Align = blackfriday.TableAlignmentRight
+		case east.AlignCenter:
+			node.Align = blackfriday.TableAlignmentCenter
+		}
+		return node
+	case *east.FootnoteList:
+		node := c.container(blackfriday.List, n)
+		node.ListFlags = blackfriday.ListTypeOrdered
+		node.IsFootnotesList = true
+		return node
+	case *east.Footnote:
+		node := c.container(blackfriday.Item, n)
+		c.footnotes[n.Index] = n
+		c.items[n.Index] = node
+		return node
+	case *east.FootnoteLink:
+		node := blackfriday.NewNode(blackfriday.Link)
+		node.NoteID = n.Index
+		c.footnoteLinks = append(c.footnoteLinks, node)
+		return node
+	case *gast.Emphasis:
+		if n.Level == 2 {
+			return c.container(blackfriday.Strong, n)
+		}
+		return c.container(blackfriday.Emph, n)
+	case *east.Strikethrough:
+		return c.container(blackfriday.Del, n)
+	case *gast.CodeSpan:
+		// line endings inside code spans become spaces
+		var code []byte
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			t, ok := child.(*gast.Text)
+			if !ok {
+				continue
+			}
+			value := t.Segment.Value(c.source)
+			if bytes.HasSuffix(value, []byte("\n")) {
+				code = append(code, value[:len(value)-1]...)
+				if child != n.LastChild() {
+					code = append(code, ' ')
+				}
+			} else {
+				code = append(code, value...)
+			}
+		}
+		node := blackfriday.NewNode(blackfriday.Code)
+		node.Literal = code
+		return node
+	case *gast.Link:
+		node := c.container(blackfriday.Link, n)
+		node.Destination = unescapeMarkdown(n.Destination)
+		// titles are left as written, like blackfriday does
+		node.Title = n.Title
+		return node
+	case *gast.AutoLink:
+		node := blackfriday.NewNode(blackfriday.Link)
+		node.Destination = n.URL(c.source)
+		if n.AutoLinkType == gast.AutoLinkEmail && !bytes.HasPrefix(node.Destination, []byte("mailto:")) {
+			node.Destination = append([]byte("mailto:"), node.Destination...)
+		}
+		label := blackfriday.NewNode(blackfriday.Text)
+		label.Literal = n.Label(c.source)
+		node.AppendChild(label)
+		return node
+	case *gast.Image:
+		node := c.container(blackfriday.Image, n)
+		node.Destination = unescapeMarkdown(n.Destination)
+		node.Title = n.Title
+		return node
+	case *gast.RawHTML:
+		node := blackfriday.NewNode(blackfriday.HTMLSpan)
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			node.Literal = append(node.Literal, seg.Value(c.source)...)
+		}
+		return node
+	}
+	return nil
+}
+
+// container converts the children of n and adds them to a new node. Runs of text are joined into
+// a single text node, like blackfriday does.
+func (c *goldmarkConverter) container(typ blackfriday.NodeType, n gast.Node) *blackfriday.Node {
+	node := blackfriday.NewNode(typ)
+
+	var text []byte
+	inText := false
+	flush := func() {
+		if inText {
+			t := blackfriday.NewNode(blackfriday.Text)
+			t.Literal = text
+			node.AppendChild(t)
+		}
+		text, inText = nil, false
+	}
+
+	var add func(n gast.Node)
+	add = func(n gast.Node) {
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			switch child := child.(type) {
+			case *gast.Text:
+				value := child.Segment.Value(c.source)
+				if !child.IsRaw() {
+					value = unescapeMarkdown(value)
+				}
+				text = append(text, value...)
+				inText = true
+				if child.HardLineBreak() {
+					flush()
+					node.AppendChild(blackfriday.NewNode(blackfriday.Hardbreak))
+				} else if child.SoftLineBreak() {
+					text = append(text, '\n')
+				}
+			case *gast.String:
+				value := child.Value
+				if !child.IsRaw() && !child.IsCode() {
+					value = unescapeMarkdown(value)
+				}
+				text = append(text, value...)
+				inText = true
+			default:
+				if converted := c.convert(child); converted != nil {
+					flush()
+					node.AppendChild(converted)
+				} else {
+					add(child)
+				}
+			}
+		}
+	}
+	add(n)
+	flush()
+
+	return node
+}
+
+// table splits the rows of a table into a head and body, since goldmark doesn't.
+func (c *goldmarkConverter) table(n *east.Table) *blackfriday.Node {
+	node := blackfriday.NewNode(blackfriday.Table)
+	var body *blackfriday.Node
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		row := c.container(blackfriday.TableRow, child)
+		if _, ok := child.(*east.TableHeader); ok {
+			for cell := row.FirstChild; cell != nil; cell = cell.Next {
+				cell.IsHeader = true
+			}
+			head := blackfriday.NewNode(blackfriday.TableHead)
+			head.AppendChild(row)
+			node.AppendChild(head)
+			continue
+		}
+
+		if body == nil {
+			body = blackfriday.NewNode(blackfriday.TableBody)
+			node.AppendChild(body)
+		}
+		body.AppendChild(row)
+	}
+	return node
+}
+
+func (c *goldmarkConverter) lines(n gast.Node) []byte {
+	var b []byte
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		b = append(b, line.Value(c.source)...)
+	}
+	return b
+}
+
+// unescapeMarkdown resolves backslash escapes and character references in one pass, so an
+// escaped ampersand doesn't start a reference.
+func unescapeMarkdown(b []byte) []byte {
+	var out []byte
+	start := 0
+	for i := 0; i < len(b)-1; i++ {
+		if b[i] == '\\' && util.IsPunct(b[i+1]) {
+			out = append(out, util.ResolveEntityNames(util.ResolveNumericReferences(b[start:i]))...)
+			out = append(out, b[i+1])
+			i++
+			start = i + 1
+		}
+	}
+	return append(out, util.ResolveEntityNames(util.ResolveNumericReferences(b[start:]))...)
+}
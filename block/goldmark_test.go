@@ -0,0 +1,142 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoldmarkCommonMark(t *testing.T) {
+	span := func(text string, marks ...string) Block {
+		return Block{Type: "span", Content: &SpanContent{Text: text, Marks: marks}}
+	}
+	text := func(style string, children ...Block) Block {
+		return Block{
+			Type: "block",
+			Content: &BlockContent{
+				Style:    style,
+				Children: children,
+				MarkDefs: []MarkDef{},
+			},
+		}
+	}
+	item := func(children ...Block) Block {
+		b := text("normal", children...)
+		b.Content.(*BlockContent).ListItem = "bullet"
+		b.Content.(*BlockContent).Level = 1
+		return b
+	}
+	continuation := func(children ...Block) Block {
+		b := text("normal", children...)
+		b.Content.(*BlockContent).Level = 1
+		return b
+	}
+
+	cases := []struct {
+		name   string
+		input  string
+		output []Block
+	}{
+		{
+			name:   "paragraph continuing a list item",
+			input:  "- a\n- b\n\n  more b\n\nAfter",
+			output: []Block{item(span("a")), item(span("b")), continuation(span("more b")), text("normal", span("After"))},
+		},
+		{
+			name:   "nested emphasis",
+			input:  "*foo**bar**baz*",
+			output: []Block{text("normal", span("foo", "em"), span("bar", "strong", "em"), span("baz", "em"))},
+		},
+		{
+			name:   "escapes and entities",
+			input:  `\*not emphasis\* &amp; &#35;1`,
+			output: []Block{text("normal", span("*not emphasis* & #1"))},
+		},
+		{
+			name:   "hard and soft breaks",
+			input:  "one  \ntwo\nthree",
+			output: []Block{text("normal", span("one\ntwo three"))},
+		},
+	}
+
+	mc := NewMarkdownConverter(WithMarkdownParser(GoldmarkParser))
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, err := mc.ToBlocks(c.input)
+			assert.NoError(t, err)
+			assert.Equal(t, c.output, out)
+		})
+	}
+}
+
+func TestGoldmarkRules(t *testing.T) {
+	mc := NewMarkdownConverter(
+		WithMarkdownParser(GoldmarkParser),
+		WithMarkdownRules(MentionMarkdownRule),
+		WithDecoratorSyntax(DefaultDecoratorSyntax))
+
+	out, err := mc.ToBlocks("Hi @someone@example.social, ==look== at <https://example.com>")
+	assert.NoError(t, err)
+	assert.Len(t, out, 1)
+
+	bc := out[0].Content.(*BlockContent)
+	assert.Equal(t, []Block{
+		{Type: "span", Content: &SpanContent{Text: "Hi "}},
+		{Type: TypeMention, Content: &MentionContent{Username: "someone", Instance: "example.social", URL: "https://example.social/@someone"}},
+		{Type: "span", Content: &SpanContent{Text: ", "}},
+		{Type: "span", Content: &SpanContent{Text: "look", Marks: []string{"highlight"}}},
+		{Type: "span", Content: &SpanContent{Text: " at "}},
+		{Type: "span", Content: &SpanContent{Text: "https://example.com", Marks: []string{"mark1"}}},
+	}, bc.Children)
+	assert.Equal(t, []MarkDef{{Type: "link", Key: "mark1", Data: &LinkData{Href: "https://example.com"}}}, bc.MarkDefs)
+}
+
+func TestListStart(t *testing.T) {
+	input := "3. Three\n4. Four\n    1. Nested\n\nAfter\n\n7. Seven"
+
+	para := func(text string) Block {
+		return Block{
+			Type: "block",
+			Content: &BlockContent{
+				Style:    "normal",
+				Children: []Block{{Type: "span", Content: &SpanContent{Text: text}}},
+				MarkDefs: []MarkDef{},
+			},
+		}
+	}
+	item := func(text string, level int, start int) Block {
+		b := para(text)
+		bc := b.Content.(*BlockContent)
+		bc.ListItem = "number"
+		bc.Level = level
+		bc.ListStart = start
+		return b
+	}
+
+	// blackfriday doesn't keep the start number, so the same input is numbered from 1
+	expected := map[string][]Block{
+		"blackfriday": {item("Three", 1, 0), item("Four", 1, 0), item("Nested", 2, 0), para("After"), item("Seven", 1, 0)},
+		"goldmark":    {item("Three", 1, 3), item("Four", 1, 0), item("Nested", 2, 0), para("After"), item("Seven", 1, 7)},
+	}
+
+	for _, p := range markdownParsers {
+		t.Run(p.name, func(t *testing.T) {
+			out, err := NewMarkdownConverter(WithMarkdownParser(p.parser)).ToBlocks(input)
+			assert.NoError(t, err)
+			assert.Equal(t, expected[p.name], out)
+		})
+	}
+
+	blocks := expected["goldmark"]
+	assert.Equal(t, "3. Three\n4. Four\n    1. Nested\n\nAfter\n\n7. Seven", ToMarkdown(blocks))
+	assert.Equal(t, "3. Three\n4. Four\n  1. Nested\n\nAfter\n\n7. Seven", ToPlainText(blocks, WithListMarkers()))
+
+	html := NewHTMLRenderer().ToHTML(blocks)
+	assert.Equal(t, `<ol start="3"><li>Three</li><li>Four<ol><li>Nested</li></ol></li></ol>`+"\n"+
+		"<p>After</p>\n"+`<ol start="7"><li>Seven</li></ol>`+"\n", html)
+
+	out, err := NewHTMLConverter().ToBlocks(html)
+	assert.NoError(t, err)
+	assert.Equal(t, blocks, out)
+}
@@ -0,0 +1,521 @@
+package block
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// HTMLTypeFunc renders a custom block or inline object to HTML. The returned string is not
+// escaped, so it must escape any text it includes.
+type HTMLTypeFunc func(b Block) string
+
+// HTMLMarkFunc returns the opening and closing HTML for a mark. For annotations, def is the mark
+// definition the span refers to; for decorators it is nil.
+type HTMLMarkFunc func(def *MarkDef) (string, string)
+
+type HTMLRenderer struct {
+	types    map[string]HTMLTypeFunc
+	inline   map[string]HTMLTypeFunc
+	marks    map[string]HTMLMarkFunc
+	imageURL func(assetID string) string
+}
+
+type HTMLOption interface {
+	Apply(r *HTMLRenderer)
+}
+
+type htmlOptionFn func(r *HTMLRenderer)
+
+func (fn htmlOptionFn) Apply(r *HTMLRenderer) {
+	fn(r)
+}
+
+// WithHTMLType sets how blocks of a custom type are rendered.
+func WithHTMLType(typeName string, fn HTMLTypeFunc) HTMLOption {
+	return htmlOptionFn(func(r *HTMLRenderer) {
+		r.types[typeName] = fn
+	})
+}
+
+// WithHTMLInline sets how inline objects of a custom type are rendered inside a block.
+func WithHTMLInline(typeName string, fn HTMLTypeFunc) HTMLOption {
+	return htmlOptionFn(func(r *HTMLRenderer) {
+		r.inline[typeName] = fn
+	})
+}
+
+// WithHTMLMark sets how a decorator, or an annotation with the given mark definition type, is
+// rendered.
+func WithHTMLMark(name string, fn HTMLMarkFunc) HTMLOption {
+	return htmlOptionFn(func(r *HTMLRenderer) {
+		r.marks[name] = fn
+	})
+}
+
+// WithHTMLImageURL sets how the asset reference of a mainImage block is turned into an image
+// URL. By default, the asset ID is used as is.
+func WithHTMLImageURL(fn func(assetID string) string) HTMLOption {
+	return htmlOptionFn(func(r *HTMLRenderer) {
+		r.imageURL = fn
+	})
+}
+
+// WithHTMLInternalLinkURL sets how the document reference of an internalLink is turned into a
+// URL. By default, internal links are rendered as plain text.
+func WithHTMLInternalLinkURL(fn func(ref Reference) string) HTMLOption {
+	return htmlOptionFn(func(r *HTMLRenderer) {
+		r.marks[MarkInternalLink] = func(def *MarkDef) (string, string) {
+			var link InternalLinkData
+			decodeContent(def.Data, &link)
+			return anchor(fn(link.Reference))
+		}
+	})
+}
+
+func NewHTMLRenderer(opts ...HTMLOption) *HTMLRenderer {
+	r := &HTMLRenderer{
+		types:  make(map[string]HTMLTypeFunc),
+		inline: make(map[string]HTMLTypeFunc),
+		marks: map[string]HTMLMarkFunc{
+			"strong":         tagMark("strong"),
+			"em":             tagMark("em"),
+			"code":           tagMark("code"),
+			"underline":      tagMark("u"),
+			"strike-through": tagMark("s"),
+			"highlight":      tagMark("mark"),
+			"sup":            tagMark("sup"),
+			"sub":            tagMark("sub"),
+			"link":           linkMark,
+			MarkFootnote:     footnoteHTMLMark,
+		},
+		imageURL: func(assetID string) string {
+			return assetID
+		},
+	}
+	r.types[TypeCode] = codeToHTML
+	r.types[TypeTweet] = tweetToHTML
+	r.types[TypeYouTube] = youTubeToHTML
+	r.types[TypeVimeo] = embedToHTML
+	r.types[TypeInstagram] = embedToHTML
+	r.types[TypeMastodon] = embedToHTML
+	r.types[TypeGist] = embedToHTML
+	r.types[TypeCodePen] = embedToHTML
+	r.types[TypeSpotify] = embedToHTML
+	r.types[TypeBluesky] = embedToHTML
+	r.types[TypeMainImage] = r.imageToHTML
+	r.types[TypeTable] = tableToHTML
+	r.types[TypeBreak] = breakToHTML
+	r.inline[TypeMention] = mentionToHTML
+
+	for _, o := range opts {
+		o.Apply(r)
+	}
+	return r
+}
+
+func tagMark(tag string) HTMLMarkFunc {
+	return func(*MarkDef) (string, string) {
+		return "<" + tag + ">", "</" + tag + ">"
+	}
+}
+
+func linkMark(def *MarkDef) (string, string) {
+	var link LinkData
+	decodeContent(def.Data, &link)
+	return anchor(link.Href)
+}
+
+// anchor returns the tags for a link to href. Links to anything but web pages, email addresses and
+// relative URLs are dropped, leaving just their text, since a javascript: URL or similar could run
+// code on the page the HTML is shown on.
+func anchor(href string) (string, string) {
+	if !safeHref(href) {
+		return "", ""
+	}
+	return fmt.Sprintf(`<a href="%s">`, html.EscapeString(href)), "</a>"
+}
+
+func safeHref(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+func footnoteHTMLMark(def *MarkDef) (string, string) {
+	var fn FootnoteData
+	decodeContent(def.Data, &fn)
+	return fmt.Sprintf(`<sup id="fnref-%d"><a href="#fn-%d">`, fn.Number, fn.Number), "</a></sup>"
+}
+
+// ToHTML renders blocks as HTML. Consecutive list items are grouped into nested lists, and any
+// footnotes are listed at the end.
+func (r *HTMLRenderer) ToHTML(blocks []Block) string {
+	seen := make(map[int]bool)
+	footnotes := collectFootnotes(blocks, seen)
+	if len(footnotes) == 0 {
+		return r.render(blocks)
+	}
+
+	var s strings.Builder
+	s.WriteString(r.render(blocks))
+	s.WriteString(`<section class="footnotes"><ol>`)
+	// footnotes can reference other footnotes, so the list may grow as we go
+	for i := 0; i < len(footnotes); i++ {
+		fn := footnotes[i]
+		text := strings.TrimSuffix(r.render(fn.Text), "\n")
+		fmt.Fprintf(&s, `<li id="fn-%d">%s</li>`, fn.Number, text)
+		footnotes = append(footnotes, collectFootnotes(fn.Text, seen)...)
+	}
+	s.WriteString("</ol></section>\n")
+	return s.String()
+}
+
+func (r *HTMLRenderer) render(blocks []Block) string {
+	var s strings.Builder
+
+	// consecutive blockquote blocks, and any lists in them, share a <blockquote>
+	var inQuote bool
+
+	// the tag of each list we are inside. every list has an open <li> while it's on the stack.
+	var lists []string
+	closeLists := func(depth int) {
+		for len(lists) > depth {
+			fmt.Fprintf(&s, "</li></%s>", lists[len(lists)-1])
+			lists = lists[:len(lists)-1]
+			if len(lists) == 0 && !inQuote {
+				s.WriteString("\n")
+			}
+		}
+	}
+
+	for i, b := range blocks {
+		bc, isText := b.Content.(*BlockContent)
+		isItem := isText && bc.ListItem != "" && bc.Level > 0
+		isContinuation := isText && bc.ListItem == "" && bc.Level > 0 && len(lists) >= bc.Level
+		isQuote := isText && bc.Style == "blockquote"
+		// a quote that continues a list item that isn't itself quoted goes inside the item
+		innerQuote := isContinuation && isQuote && !inQuote
+		if innerQuote {
+			isQuote = false
+		}
+		// so does a custom block between parts of a list item
+		var customLevel int
+		if !isText {
+			if level := continuationLevel(blocks, i+1); level <= len(lists) {
+				customLevel = level
+			}
+		}
+
+		if !isItem && !isContinuation && customLevel == 0 {
+			closeLists(0)
+		}
+		if inQuote != isQuote {
+			closeLists(0)
+			if inQuote {
+				s.WriteString("</blockquote>\n")
+			} else {
+				s.WriteString("<blockquote>")
+			}
+			inQuote = isQuote
+		}
+
+		if !isText {
+			fn, ok := r.types[b.Type]
+			if customLevel > 0 {
+				closeLists(customLevel)
+				if ok {
+					s.WriteString(fn(b))
+				}
+			} else if ok {
+				s.WriteString(fn(b))
+				s.WriteString("\n")
+			}
+			continue
+		}
+
+		text := r.spansToHTML(bc)
+
+		if isContinuation {
+			closeLists(bc.Level)
+			if innerQuote {
+				fmt.Fprintf(&s, "<blockquote>%s</blockquote>", textToHTML(bc.Style, text))
+			} else {
+				s.WriteString(textToHTML(bc.Style, text))
+			}
+			continue
+		}
+
+		if isItem {
+			tag := "ul"
+			if bc.ListItem == "number" {
+				tag = "ol"
+			}
+
+			closeLists(bc.Level)
+			if len(lists) == bc.Level {
+				// an item with a start number begins a new list
+				if lists[len(lists)-1] == tag && bc.ListStart == 0 {
+					s.WriteString("</li>")
+				} else {
+					closeLists(bc.Level - 1)
+				}
+			}
+			for len(lists) < bc.Level {
+				if tag == "ol" && bc.ListStart > 0 && len(lists) == bc.Level-1 {
+					fmt.Fprintf(&s, `<ol start="%d">`, bc.ListStart)
+				} else {
+					fmt.Fprintf(&s, "<%s>", tag)
+				}
+				lists = append(lists, tag)
+				if len(lists) < bc.Level {
+					s.WriteString("<li>")
+				}
+			}
+
+			s.WriteString("<li>")
+			paras := strings.Split(text, "\n\n")
+			if len(paras) > 1 || isHeading(bc.Style) || continuesAt(blocks, i+1, bc.Level) {
+				s.WriteString(textToHTML(bc.Style, text))
+			} else {
+				s.WriteString(breakLines(text))
+			}
+			continue
+		}
+
+		s.WriteString(textToHTML(bc.Style, text))
+		if !inQuote {
+			s.WriteString("\n")
+		}
+	}
+	closeLists(0)
+	if inQuote {
+		s.WriteString("</blockquote>\n")
+	}
+
+	return s.String()
+}
+
+// textToHTML wraps the text of a block in a heading or paragraphs.
+func textToHTML(style string, text string) string {
+	if isHeading(style) {
+		return fmt.Sprintf("<%s>%s</%s>", style, breakLines(text), style)
+	}
+	return wrapParagraphs(strings.Split(text, "\n\n"))
+}
+
+func isHeading(style string) bool {
+	switch style {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return true
+	}
+	return false
+}
+
+// continuesAt checks whether the block at i continues a list item at the given level, possibly
+// after some custom blocks.
+func continuesAt(blocks []Block, i int, level int) bool {
+	for ; i < len(blocks); i++ {
+		bc, ok := blocks[i].Content.(*BlockContent)
+		if ok {
+			return bc.ListItem == "" && bc.Level == level
+		}
+	}
+	return false
+}
+
+// collectFootnotes finds the footnotes referenced in blocks, leaving out any whose number is
+// already in seen, so a footnote referenced more than once is only listed once.
+func collectFootnotes(blocks []Block, seen map[int]bool) []FootnoteData {
+	var footnotes []FootnoteData
+	for _, b := range blocks {
+		bc, ok := b.Content.(*BlockContent)
+		if !ok {
+			continue
+		}
+		for _, md := range bc.MarkDefs {
+			if md.Type != MarkFootnote {
+				continue
+			}
+			var fn FootnoteData
+			if decodeContent(md.Data, &fn) && len(fn.Text) > 0 && !seen[fn.Number] {
+				seen[fn.Number] = true
+				footnotes = append(footnotes, fn)
+			}
+		}
+	}
+	return footnotes
+}
+
+func wrapParagraphs(paras []string) string {
+	var s strings.Builder
+	for _, p := range paras {
+		fmt.Fprintf(&s, "<p>%s</p>", breakLines(p))
+	}
+	return s.String()
+}
+
+func breakLines(text string) string {
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// spansToHTML renders the children of a block. Newlines are left in the output for the caller
+// to turn into paragraphs or line breaks.
+func (r *HTMLRenderer) spansToHTML(bc *BlockContent) string {
+	markDefs := make(map[string]*MarkDef)
+	for i := range bc.MarkDefs {
+		markDefs[bc.MarkDefs[i].Key] = &bc.MarkDefs[i]
+	}
+
+	var s strings.Builder
+	var open []string
+	var closers []string
+
+	for _, child := range bc.Children {
+		var marks []string
+		if sc, ok := child.Content.(*SpanContent); ok {
+			marks = sc.Marks
+		}
+
+		keep := 0
+		for keep < len(open) && hasMark(marks, open[keep]) {
+			keep++
+		}
+		for i := len(open) - 1; i >= keep; i-- {
+			s.WriteString(closers[i])
+		}
+		open, closers = open[:keep], closers[:keep]
+
+		for _, mark := range orderedMarks(marks, isMarkdownDecorator) {
+			if hasMark(open, mark) {
+				continue
+			}
+
+			var fn HTMLMarkFunc
+			def := markDefs[mark]
+			if def != nil {
+				fn = r.marks[def.Type]
+			} else {
+				fn = r.marks[mark]
+			}
+
+			opener, closer := "", ""
+			if fn != nil {
+				opener, closer = fn(def)
+			}
+			s.WriteString(opener)
+			open = append(open, mark)
+			closers = append(closers, closer)
+		}
+
+		if sc, ok := child.Content.(*SpanContent); ok {
+			s.WriteString(html.EscapeString(sc.Text))
+		} else if fn, ok := r.inline[child.Type]; ok {
+			s.WriteString(fn(child))
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		s.WriteString(closers[i])
+	}
+
+	return s.String()
+}
+
+func codeToHTML(b Block) string {
+	var cc CodeContent
+	decodeContent(b.Content, &cc)
+
+	if cc.Language == "" {
+		return fmt.Sprintf("<pre><code>%s</code></pre>", html.EscapeString(cc.Code))
+	}
+	return fmt.Sprintf(`<pre><code class="language-%s">%s</code></pre>`,
+		html.EscapeString(cc.Language), html.EscapeString(cc.Code))
+}
+
+func tableToHTML(b Block) string {
+	var tc TableContent
+	decodeContent(b.Content, &tc)
+
+	var s strings.Builder
+	s.WriteString("<table>")
+	rows := tc.Rows
+	if len(rows) > 0 && rows[0].Header {
+		s.WriteString("<thead><tr>")
+		for _, cell := range rows[0].Cells {
+			fmt.Fprintf(&s, "<th>%s</th>", html.EscapeString(cell))
+		}
+		s.WriteString("</tr></thead>")
+		rows = rows[1:]
+	}
+	s.WriteString("<tbody>")
+	for _, row := range rows {
+		s.WriteString("<tr>")
+		for _, cell := range row.Cells {
+			fmt.Fprintf(&s, "<td>%s</td>", html.EscapeString(cell))
+		}
+		s.WriteString("</tr>")
+	}
+	s.WriteString("</tbody></table>")
+	return s.String()
+}
+
+func breakToHTML(Block) string {
+	return "<hr>"
+}
+
+func tweetToHTML(b Block) string {
+	var tc TweetContent
+	decodeContent(b.Content, &tc)
+
+	opener, closer := anchor(tc.URL)
+	return fmt.Sprintf(`<blockquote class="twitter-tweet">%s%s%s</blockquote>`,
+		opener, html.EscapeString(tc.URL), closer)
+}
+
+func youTubeToHTML(b Block) string {
+	var yc YouTubeContent
+	decodeContent(b.Content, &yc)
+
+	id := yc.ID
+	if id == "" {
+		if content, ok := matchEmbed(yc.URL, YouTubeMatchers); ok {
+			id = content.ID
+		}
+	}
+	if id == "" {
+		return embedToHTML(b)
+	}
+
+	embedURL := "https://www.youtube.com/embed/" + url.PathEscape(id)
+	return fmt.Sprintf(`<iframe width="560" height="315" src="%s" frameborder="0" allowfullscreen></iframe>`,
+		html.EscapeString(embedURL))
+}
+
+// embedToHTML renders embedded content as a link to it, for types without a richer embed.
+func embedToHTML(b Block) string {
+	var content EmbedContent
+	decodeContent(b.Content, &content)
+
+	opener, closer := anchor(content.URL)
+	return fmt.Sprintf(`<p>%s%s%s</p>`, opener, html.EscapeString(content.URL), closer)
+}
+
+func (r *HTMLRenderer) imageToHTML(b Block) string {
+	var ic ImageContent
+	decodeContent(b.Content, &ic)
+
+	img := fmt.Sprintf(`<img src="%s" alt="%s">`,
+		html.EscapeString(r.imageURL(string(ic.Asset))), html.EscapeString(ic.Alt))
+	if ic.Caption == "" {
+		return img
+	}
+	return fmt.Sprintf("<figure>%s<figcaption>%s</figcaption></figure>", img, html.EscapeString(ic.Caption))
+}
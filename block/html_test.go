@@ -0,0 +1,165 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToHTML(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{
+			name:   "paragraphs with formatting",
+			input:  "This is _some text_ with **formatting** & `<code>`.\n\nAnd another paragraph.",
+			output: "<p>This is <em>some text</em> with <strong>formatting</strong> &amp; <code>&lt;code&gt;</code>.</p>\n<p>And another paragraph.</p>\n",
+		},
+		{
+			name:   "line breaks",
+			input:  "One line  \nand another.",
+			output: "<p>One line<br>and another.</p>\n",
+		},
+		{
+			name:   "headings",
+			input:  "# Heading 1\n\n### Heading 3",
+			output: "<h1>Heading 1</h1>\n<h3>Heading 3</h3>\n",
+		},
+		{
+			name:   "nested lists",
+			input:  "* One\n* Two\n    1. Nested\n    2. Nested again\n* Three\n\nAfter.",
+			output: "<ul><li>One</li><li>Two<ol><li>Nested</li><li>Nested again</li></ol></li><li>Three</li></ul>\n<p>After.</p>\n",
+		},
+		{
+			name:   "list items with paragraphs",
+			input:  "1. One\n\n    Continued\n2. Two",
+			output: "<ol><li><p>One</p><p>Continued</p></li><li>Two</li></ol>\n",
+		},
+		{
+			name:   "blockquotes",
+			input:  "> A quote.\n>\n> Second paragraph.",
+			output: "<blockquote><p>A quote.</p><p>Second paragraph.</p></blockquote>\n",
+		},
+		{
+			name:   "links",
+			input:  `A [link with **bold**](https://example.com/?a=1&b=2) here.`,
+			output: "<p>A <a href=\"https://example.com/?a=1&amp;b=2\">link with <strong>bold</strong></a> here.</p>\n",
+		},
+		{
+			name:   "unsafe links",
+			input:  "A [script](javascript:alert\\(1\\)) and [data](data:text/html,hi) and [mail](mailto:me@example.com) and [relative](/about).",
+			output: "<p>A script and data and <a href=\"mailto:me@example.com\">mail</a> and <a href=\"/about\">relative</a>.</p>\n",
+		},
+		{
+			name:   "code blocks",
+			input:  "```go\nif a < b {}\n```",
+			output: "<pre><code class=\"language-go\">if a &lt; b {}</code></pre>\n",
+		},
+		{
+			name:   "strikethrough and rules",
+			input:  "Some ~~old~~ text.\n\n---",
+			output: "<p>Some <s>old</s> text.</p>\n<hr>\n",
+		},
+		{
+			name:   "tables",
+			input:  "| A | B |\n| --- | --- |\n| 1 | a & b |",
+			output: "<table><thead><tr><th>A</th><th>B</th></tr></thead><tbody><tr><td>1</td><td>a &amp; b</td></tr></tbody></table>\n",
+		},
+		{
+			name:   "footnotes",
+			input:  "Some text.[^1]\n\n[^1]: A _note_.",
+			output: "<p>Some text.<sup id=\"fnref-1\"><a href=\"#fn-1\">1</a></sup></p>\n<section class=\"footnotes\"><ol><li id=\"fn-1\"><p>A <em>note</em>.</p></li></ol></section>\n",
+		},
+		{
+			name:   "lists in blockquotes",
+			input:  "> Quoted list:\n>\n> * One\n> * Two\n>\n> After the list",
+			output: "<blockquote><p>Quoted list:</p><ul><li>One</li><li>Two</li></ul><p>After the list</p></blockquote>\n",
+		},
+		{
+			name:   "nested content in list items",
+			input:  "1. ## A heading\n\n        x := 1\n\n    After the code\n\n    > Quoted\n2. Two",
+			output: "<ol><li><h2>A heading</h2><pre><code>x := 1</code></pre><p>After the code</p><blockquote><p>Quoted</p></blockquote></li><li>Two</li></ol>\n",
+		},
+	}
+
+	mc := NewMarkdownConverter()
+	r := NewHTMLRenderer()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blocks, err := mc.ToBlocks(c.input)
+			assert.NoError(t, err)
+			assert.Equal(t, c.output, r.ToHTML(blocks))
+		})
+	}
+}
+
+func TestToHTMLFootnoteReferencedTwice(t *testing.T) {
+	mc := NewMarkdownConverter(WithMarkdownParser(GoldmarkParser))
+	blocks, err := mc.ToBlocks("One.[^a]\n\nTwo.[^a]\n\n[^a]: A note.")
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>One.<sup id=\"fnref-1\"><a href=\"#fn-1\">1</a></sup></p>\n<p>Two.<sup id=\"fnref-1\"><a href=\"#fn-1\">1</a></sup></p>\n<section class=\"footnotes\"><ol><li id=\"fn-1\"><p>A note.</p></li></ol></section>\n", NewHTMLRenderer().ToHTML(blocks))
+}
+
+func TestToHTMLCustomSerializers(t *testing.T) {
+	blocks := []Block{
+		{
+			Type:    TypeYouTube,
+			Content: &YouTubeContent{URL: "https://www.youtube.com/watch?v=TamwFUUd9Yk"},
+		},
+		{
+			Type: "mainImage",
+			Content: map[string]interface{}{
+				"alt":   `A "photo"`,
+				"asset": map[string]interface{}{"_type": "reference", "_ref": "image-abc"},
+			},
+		},
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Content: &SpanContent{Text: "Hi ", Marks: []string{"highlight"}}},
+					{Type: "mention", Content: map[string]interface{}{"handle": "@mjm"}},
+					{Type: "span", Content: &SpanContent{Text: "!", Marks: []string{"m1"}}},
+				},
+				MarkDefs: []MarkDef{
+					{Type: "footnote", Key: "m1", Data: map[string]interface{}{"n": 1}},
+				},
+			},
+		},
+	}
+
+	r := NewHTMLRenderer(
+		WithHTMLImageURL(func(assetID string) string {
+			return "https://cdn.example.com/" + assetID
+		}),
+		WithHTMLInline("mention", func(b Block) string {
+			return `<a class="h-card">` + b.Content.(map[string]interface{})["handle"].(string) + "</a>"
+		}),
+		WithHTMLMark("footnote", func(def *MarkDef) (string, string) {
+			return "", "<sup>1</sup>"
+		}))
+
+	assert.Equal(t, `<iframe width="560" height="315" src="https://www.youtube.com/embed/TamwFUUd9Yk" frameborder="0" allowfullscreen></iframe>
+<img src="https://cdn.example.com/image-abc" alt="A &#34;photo&#34;">
+<p><mark>Hi </mark><a class="h-card">@mjm</a>!<sup>1</sup></p>
+`, r.ToHTML(blocks))
+}
+
+func TestToHTMLUnsafeHrefs(t *testing.T) {
+	for _, href := range []string{"javascript:alert(1)", "JavaScript:alert(1)", " javascript:alert(1)", "java\tscript:alert(1)", "vbscript:x"} {
+		t.Run(href, func(t *testing.T) {
+			b := New("normal", Text("click", "mark1"))
+			b.Content.(*BlockContent).MarkDefs = []MarkDef{
+				{Type: "link", Key: "mark1", Data: &LinkData{Href: href}},
+			}
+			mention := Block{Type: TypeMention, Content: &MentionContent{Username: "someone", Instance: "example.social", URL: href}}
+			b.Content.(*BlockContent).Children = append(b.Content.(*BlockContent).Children, mention)
+
+			assert.Equal(t, "<p>click@someone@example.social</p>\n", NewHTMLRenderer().ToHTML([]Block{b}))
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package block
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+const TypeMainImage = "mainImage"
+
+type ImageContent struct {
+	Alt     string        `json:"alt,omitempty"`
+	Caption string        `json:"caption,omitempty"`
+	Asset   Reference     `json:"asset"`
+	Crop    *ImageCrop    `json:"crop,omitempty"`
+	Hotspot *ImageHotspot `json:"hotspot,omitempty"`
+}
+
+// ImageCrop is the part of an image chosen in the Studio, as fractions of the image size to trim
+// from each side.
+type ImageCrop struct {
+	Type   string  `json:"_type,omitempty"`
+	Top    float64 `json:"top"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+	Right  float64 `json:"right"`
+}
+
+// ImageHotspot is the area of an image that should stay visible when it's cropped to fit, with
+// its center and size as fractions of the image size.
+type ImageHotspot struct {
+	Type   string  `json:"_type,omitempty"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// PlainText returns the image's caption, or its alt text if it has no caption.
+func (c *ImageContent) PlainText() string {
+	if c.Caption != "" {
+		return c.Caption
+	}
+	return c.Alt
+}
+
+// ImageResolver turns the URL of an image in Markdown into a reference to a Sanity image asset.
+type ImageResolver interface {
+	ResolveImage(ctx context.Context, src string) (Reference, error)
+}
+
+type ImageResolverFunc func(ctx context.Context, src string) (Reference, error)
+
+func (fn ImageResolverFunc) ResolveImage(ctx context.Context, src string) (Reference, error) {
+	return fn(ctx, src)
+}
+
+// ImageStore looks up and creates image assets. *mpsanity.Client implements it.
+type ImageStore interface {
+	// ImageAssetID returns the ID of the asset that an image URL refers to, if it's already an
+	// image in the dataset.
+	ImageAssetID(imageURL string) (string, bool)
+	UploadImage(ctx context.Context, body io.Reader) (string, error)
+}
+
+// DefaultMaxImageSize is the largest image SanityImageResolver downloads if it has no MaxSize.
+const DefaultMaxImageSize = 20 << 20
+
+// SanityImageResolver resolves images that are already in the client's dataset to their existing
+// asset, and uploads any other image to create a new asset. Only http and https images are
+// downloaded.
+type SanityImageResolver struct {
+	Client ImageStore
+	// HTTPClient downloads images. Without one, http.DefaultClient is used.
+	HTTPClient *http.Client
+	// MaxSize is the largest image in bytes that will be downloaded.
+	MaxSize int64
+}
+
+func (r *SanityImageResolver) ResolveImage(ctx context.Context, src string) (Reference, error) {
+	if id, ok := r.Client.ImageAssetID(src); ok {
+		return Reference(id), nil
+	}
+
+	u, err := url.Parse(src)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("image %s is not an http or https URL", src)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return "", err
+	}
+
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode > 299 {
+		return "", fmt.Errorf("unexpected status code %d for %s", res.StatusCode, src)
+	}
+
+	maxSize := r.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxImageSize
+	}
+	if res.ContentLength > maxSize {
+		return "", fmt.Errorf("image %s is larger than %d bytes", src, maxSize)
+	}
+
+	// the length isn't always known up front, so read one byte past the limit to catch big images
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, maxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > maxSize {
+		return "", fmt.Errorf("image %s is larger than %d bytes", src, maxSize)
+	}
+
+	id, err := r.Client.UploadImage(ctx, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return Reference(id), nil
+}
+
+// WithImageResolver enables converting Markdown images to image blocks, using r to find or
+// upload the image asset. Without a resolver, only the alt text of an image is kept.
+func WithImageResolver(r ImageResolver) MarkdownOption {
+	return markdownOptionFn(func(mc *MarkdownConverter) {
+		mc.imageResolver = r
+	})
+}
@@ -0,0 +1,108 @@
+package block
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testImageStore struct {
+	uploaded []string
+}
+
+func (s *testImageStore) ImageAssetID(imageURL string) (string, bool) {
+	if strings.HasPrefix(imageURL, "https://cdn.example.com/") {
+		return "image-" + strings.TrimPrefix(imageURL, "https://cdn.example.com/"), true
+	}
+	return "", false
+}
+
+func (s *testImageStore) UploadImage(ctx context.Context, body io.Reader) (string, error) {
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	s.uploaded = append(s.uploaded, string(data))
+	return "image-uploaded", nil
+}
+
+func TestSanityImageResolver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small.png":
+			w.Write([]byte("small"))
+		case "/big.png":
+			w.Write([]byte(strings.Repeat("x", 100)))
+		case "/streamed.png":
+			// flushing before writing everything means the response has no content length
+			w.Write([]byte(strings.Repeat("x", 50)))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(strings.Repeat("x", 50)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	store := &testImageStore{}
+	r := &SanityImageResolver{Client: store, HTTPClient: srv.Client(), MaxSize: 10}
+	ctx := context.Background()
+
+	ref, err := r.ResolveImage(ctx, "https://cdn.example.com/abc-png")
+	assert.NoError(t, err)
+	assert.Equal(t, Reference("image-abc-png"), ref)
+
+	ref, err = r.ResolveImage(ctx, srv.URL+"/small.png")
+	assert.NoError(t, err)
+	assert.Equal(t, Reference("image-uploaded"), ref)
+	assert.Equal(t, []string{"small"}, store.uploaded)
+
+	_, err = r.ResolveImage(ctx, srv.URL+"/big.png")
+	assert.EqualError(t, err, "image "+srv.URL+"/big.png is larger than 10 bytes")
+	_, err = r.ResolveImage(ctx, srv.URL+"/streamed.png")
+	assert.EqualError(t, err, "image "+srv.URL+"/streamed.png is larger than 10 bytes")
+	_, err = r.ResolveImage(ctx, srv.URL+"/missing.png")
+	assert.EqualError(t, err, "unexpected status code 404 for "+srv.URL+"/missing.png")
+
+	for _, src := range []string{"file:///etc/passwd", "ftp://example.com/a.png", "data:image/png;base64,AAAA", "/relative.png"} {
+		_, err = r.ResolveImage(ctx, src)
+		assert.EqualError(t, err, "image "+src+" is not an http or https URL")
+	}
+	assert.Len(t, store.uploaded, 1)
+}
+
+func TestImageCropAndHotspotRoundTrip(t *testing.T) {
+	data := `{
+		"_type": "mainImage",
+		"_key": "img",
+		"alt": "Photo",
+		"asset": {"_type": "reference", "_ref": "image-abc-png"},
+		"crop": {"_type": "sanity.imageCrop", "top": 0.1, "bottom": 0, "left": 0.25, "right": 0.05},
+		"hotspot": {"_type": "sanity.imageHotspot", "x": 0.5, "y": 0.4, "width": 0.3, "height": 0.2}
+	}`
+
+	var b Block
+	assert.NoError(t, json.Unmarshal([]byte(data), &b))
+	assert.Equal(t, &ImageContent{
+		Alt:     "Photo",
+		Asset:   "image-abc-png",
+		Crop:    &ImageCrop{Type: "sanity.imageCrop", Top: 0.1, Left: 0.25, Right: 0.05},
+		Hotspot: &ImageHotspot{Type: "sanity.imageHotspot", X: 0.5, Y: 0.4, Width: 0.3, Height: 0.2},
+	}, b.Content)
+
+	out, err := json.Marshal(b)
+	assert.NoError(t, err)
+	assert.JSONEq(t, data, string(out))
+
+	// images without them leave them out
+	out, err = json.Marshal(Block{Type: TypeMainImage, Content: &ImageContent{Asset: "image-abc-png"}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"_type": "mainImage", "asset": {"_type": "reference", "_ref": "image-abc-png"}}`, string(out))
+}
@@ -0,0 +1,106 @@
+package block
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+const TypeMention = "mention"
+
+// MentionContent is the content of an inline object mentioning a user on a fediverse instance.
+type MentionContent struct {
+	Username string `json:"username"`
+	Instance string `json:"instance"`
+	URL      string `json:"url,omitempty"`
+}
+
+func (c *MentionContent) PlainText() string {
+	return "@" + c.Username + "@" + c.Instance
+}
+
+// InlineMatchFunc turns a match of an InlineMarkdownRule's pattern into an inline object. The
+// match includes any submatches, like regexp.FindStringSubmatch. If it returns false, the match
+// is kept as text.
+type InlineMatchFunc func(match []string) (typeName string, content interface{}, ok bool)
+
+// InlineMarkdownRule creates a rule that finds matches of a pattern in text and replaces each one
+// with an inline object. Text in code spans and links is left alone.
+func InlineMarkdownRule(pattern *regexp.Regexp, fn InlineMatchFunc) MarkdownRuleFunc {
+	return func(b *Builder, node *blackfriday.Node, entering bool) (blackfriday.WalkStatus, bool) {
+		if node.Type != blackfriday.Text || inLink(node) {
+			return blackfriday.GoToNext, false
+		}
+
+		text := strings.ReplaceAll(string(node.Literal), "\n", " ")
+		matches := pattern.FindAllStringSubmatchIndex(text, -1)
+		if matches == nil {
+			return blackfriday.GoToNext, false
+		}
+
+		last := 0
+		for _, m := range matches {
+			match := make([]string, len(m)/2)
+			for i := range match {
+				if m[2*i] >= 0 {
+					match[i] = text[m[2*i]:m[2*i+1]]
+				}
+			}
+
+			typeName, content, ok := fn(match)
+			if !ok {
+				continue
+			}
+			if m[0] > last {
+				b.AppendText(text[last:m[0]])
+			}
+			b.AddInlineObject(typeName, content)
+			last = m[1]
+		}
+		if last < len(text) {
+			b.AppendText(text[last:])
+		}
+		return blackfriday.GoToNext, true
+	}
+}
+
+func inLink(node *blackfriday.Node) bool {
+	for n := node.Parent; n != nil; n = n.Parent {
+		if n.Type == blackfriday.Link {
+			return true
+		}
+	}
+	return false
+}
+
+var mentionRegex = regexp.MustCompile(`\B@(\w+)@([\w-]+(?:\.[\w-]+)+)\b`)
+
+// MentionMarkdownRule turns fediverse mentions like @user@example.social into mention inline
+// objects.
+var MentionMarkdownRule = InlineMarkdownRule(mentionRegex, func(match []string) (string, interface{}, bool) {
+	return TypeMention, &MentionContent{
+		Username: match[1],
+		Instance: strings.ToLower(match[2]),
+		URL:      fmt.Sprintf("https://%s/@%s", strings.ToLower(match[2]), match[1]),
+	}, true
+})
+
+func mentionToMarkdown(b Block) string {
+	var mc MentionContent
+	decodeContent(b.Content, &mc)
+	return mc.PlainText()
+}
+
+func mentionToHTML(b Block) string {
+	var mc MentionContent
+	decodeContent(b.Content, &mc)
+
+	text := html.EscapeString(mc.PlainText())
+	if mc.URL == "" || !safeHref(mc.URL) {
+		return text
+	}
+	return fmt.Sprintf(`<a class="mention" href="%s">%s</a>`, html.EscapeString(mc.URL), text)
+}
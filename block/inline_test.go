@@ -0,0 +1,122 @@
+package block
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownMentions(t *testing.T) {
+	mc := NewMarkdownConverter(WithMarkdownRules(MentionMarkdownRule))
+
+	out, err := mc.ToBlocks("Thanks **@someone@Example.social** and @other@hachyderm.io!\n\nNot `@code@example.com`, [@linked@example.com](https://example.com) or me@home@example.com.")
+	assert.NoError(t, err)
+
+	mention := func(user, instance string) Block {
+		return Block{
+			Type: TypeMention,
+			Content: &MentionContent{
+				Username: user,
+				Instance: instance,
+				URL:      "https://" + instance + "/@" + user,
+			},
+		}
+	}
+
+	assert.Equal(t, []Block{
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Content: &SpanContent{Text: "Thanks "}},
+					mention("someone", "example.social"),
+					{Type: "span", Content: &SpanContent{Text: " and "}},
+					mention("other", "hachyderm.io"),
+					{Type: "span", Content: &SpanContent{Text: "!"}},
+				},
+				MarkDefs: []MarkDef{},
+			},
+		},
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Content: &SpanContent{Text: "Not "}},
+					{Type: "span", Content: &SpanContent{Text: "@code@example.com", Marks: []string{"code"}}},
+					{Type: "span", Content: &SpanContent{Text: ", "}},
+					{Type: "span", Content: &SpanContent{Text: "@linked@example.com", Marks: []string{"mark1"}}},
+					{Type: "span", Content: &SpanContent{Text: " or me@home@example.com."}},
+				},
+				MarkDefs: []MarkDef{
+					{Type: "link", Key: "mark1", Data: &LinkData{Href: "https://example.com"}},
+				},
+			},
+		},
+	}, out)
+
+	assert.Equal(t, "Thanks @someone@example.social and @other@hachyderm.io!", strings.SplitN(ToMarkdown(out), "\n", 2)[0])
+	assert.Equal(t, `<p>Thanks <a class="mention" href="https://example.social/@someone">@someone@example.social</a> and <a class="mention" href="https://hachyderm.io/@other">@other@hachyderm.io</a>!</p>`,
+		strings.SplitN(NewHTMLRenderer().ToHTML(out), "\n", 2)[0])
+
+	data, err := json.Marshal(out)
+	assert.NoError(t, err)
+	var decoded []Block
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, out[0].Content.(*BlockContent).Children[1], decoded[0].Content.(*BlockContent).Children[1])
+}
+
+func TestInlineObjectKeepsMarks(t *testing.T) {
+	emoji := InlineMarkdownRule(regexp.MustCompile(`:(\w+):`), func(match []string) (string, interface{}, bool) {
+		if match[1] != "wave" {
+			return "", nil, false
+		}
+		return "emoji", map[string]interface{}{"name": match[1]}, true
+	})
+	mc := NewMarkdownConverter(WithMarkdownRules(emoji))
+
+	out, err := mc.ToBlocks("_Hi :wave: there :unknown:_")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Block{
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Content: &SpanContent{Text: "Hi ", Marks: []string{"em"}}},
+					{Type: "emoji", Content: map[string]interface{}{"name": "wave"}},
+					{Type: "span", Content: &SpanContent{Text: " there :unknown:", Marks: []string{"em"}}},
+				},
+				MarkDefs: []MarkDef{},
+			},
+		},
+	}, out)
+}
+
+func TestBuilderTextOutsideBlock(t *testing.T) {
+	b := &Builder{}
+	b.AppendText("loose text")
+	b.AddInlineObject(TypeMention, &MentionContent{Username: "someone", Instance: "example.social"})
+
+	assert.NotPanics(t, func() {
+		b.EndSpan()
+	})
+	assert.Equal(t, []Block{
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Content: &SpanContent{Text: "loose text"}},
+					{Type: TypeMention, Content: &MentionContent{Username: "someone", Instance: "example.social"}},
+				},
+				MarkDefs: []MarkDef{},
+			},
+		},
+	}, b.Blocks())
+}
@@ -0,0 +1,118 @@
+package block
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/api/trace"
+)
+
+// MarkInternalLink is the type of annotation for links to other documents in the dataset.
+const MarkInternalLink = "internalLink"
+
+type InternalLinkData struct {
+	Reference Reference `json:"reference"`
+}
+
+// LinkResolver finds the document that a link points to, so that links to other posts on the
+// site can refer to the document instead of its URL.
+type LinkResolver interface {
+	// ResolveLink returns a reference to the document at href. If href isn't a link to a document,
+	// it returns false and the link is kept as a normal link. Errors are recorded, and the link is
+	// also kept as a normal link.
+	ResolveLink(ctx context.Context, href string) (Reference, bool, error)
+}
+
+type LinkResolverFunc func(ctx context.Context, href string) (Reference, bool, error)
+
+func (fn LinkResolverFunc) ResolveLink(ctx context.Context, href string) (Reference, bool, error) {
+	return fn(ctx, href)
+}
+
+// Querier runs GROQ queries. *mpsanity.Client implements it.
+type Querier interface {
+	Query(ctx context.Context, query string, out interface{}) error
+}
+
+// SanityLinkResolver resolves links to pages under BaseURL by looking up the document whose slug
+// is the rest of the link's path. Links relative to the root of the site are resolved against
+// BaseURL too.
+type SanityLinkResolver struct {
+	Client  Querier
+	BaseURL string
+}
+
+func (r *SanityLinkResolver) ResolveLink(ctx context.Context, href string) (Reference, bool, error) {
+	slug, ok := r.slug(href)
+	if !ok {
+		return "", false, nil
+	}
+
+	var docs []struct {
+		ID string `json:"_id"`
+	}
+	q := fmt.Sprintf(`*[slug.current == %q && !(_id in path("drafts.**"))]{_id}`, slug)
+	if err := r.Client.Query(ctx, q, &docs); err != nil {
+		return "", false, err
+	}
+	if len(docs) == 0 {
+		return "", false, nil
+	}
+	return Reference(docs[0].ID), true, nil
+}
+
+func (r *SanityLinkResolver) slug(href string) (string, bool) {
+	if r.BaseURL == "" {
+		return "", false
+	}
+	base, err := url.Parse(strings.TrimSuffix(r.BaseURL, "/") + "/")
+	if err != nil {
+		return "", false
+	}
+	u, err := url.Parse(href)
+	if err != nil || (u.Host == "" && !strings.HasPrefix(u.Path, "/")) {
+		return "", false
+	}
+	u = base.ResolveReference(u)
+
+	if !strings.EqualFold(u.Host, base.Host) || !strings.HasPrefix(u.Path, base.Path) {
+		return "", false
+	}
+	slug := strings.Trim(strings.TrimPrefix(u.Path, base.Path), "/")
+	if slug == "" {
+		return "", false
+	}
+	return slug, true
+}
+
+// WithLinkResolver sets the resolver used to turn links to other documents into internalLink
+// annotations. Without one, all links are normal links.
+func WithLinkResolver(r LinkResolver) MarkdownOption {
+	return markdownOptionFn(func(mc *MarkdownConverter) {
+		mc.linkResolver = r
+	})
+}
+
+// WithHTMLLinkResolver is the HTMLConverter equivalent of WithLinkResolver.
+func WithHTMLLinkResolver(r LinkResolver) HTMLConverterOption {
+	return htmlConverterOptionFn(func(hc *HTMLConverter) {
+		hc.linkResolver = r
+	})
+}
+
+// addLink adds the mark definition for a link, as an internalLink if the resolver recognizes it.
+// If the resolver fails, the link is still worth keeping, so the error is recorded on the trace
+// and the link is kept as a normal link.
+func addLink(ctx context.Context, b *Builder, r LinkResolver, href string) string {
+	if r != nil {
+		ref, ok, err := r.ResolveLink(ctx, href)
+		if err != nil {
+			trace.SpanFromContext(ctx).RecordError(ctx, fmt.Errorf("resolving link %s: %w", href, err))
+		} else if ok {
+			return b.AddMarkDef(MarkInternalLink, &InternalLinkData{Reference: ref})
+		}
+	}
+	return b.AddMarkDef("link", &LinkData{Href: href})
+}
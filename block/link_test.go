@@ -0,0 +1,125 @@
+package block
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testLinkResolver = LinkResolverFunc(func(ctx context.Context, href string) (Reference, bool, error) {
+	switch href {
+	case "https://example.com/some-post":
+		return "post-123", true, nil
+	case "https://example.com/broken":
+		return "", false, errors.New("lookup failed")
+	}
+	return "", false, nil
+})
+
+func TestMarkdownInternalLinks(t *testing.T) {
+	mc := NewMarkdownConverter(WithLinkResolver(testLinkResolver))
+
+	out, err := mc.ToBlocks("See [my post](https://example.com/some-post) and [this](https://example.com/missing).")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Block{
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Content: &SpanContent{Text: "See "}},
+					{Type: "span", Content: &SpanContent{Text: "my post", Marks: []string{"mark1"}}},
+					{Type: "span", Content: &SpanContent{Text: " and "}},
+					{Type: "span", Content: &SpanContent{Text: "this", Marks: []string{"mark2"}}},
+					{Type: "span", Content: &SpanContent{Text: "."}},
+				},
+				MarkDefs: []MarkDef{
+					{Type: MarkInternalLink, Key: "mark1", Data: &InternalLinkData{Reference: "post-123"}},
+					{Type: "link", Key: "mark2", Data: &LinkData{Href: "https://example.com/missing"}},
+				},
+			},
+		},
+	}, out)
+
+	hc := NewHTMLConverter(WithHTMLLinkResolver(testLinkResolver))
+	htmlOut, err := hc.ToBlocks(`<p>See <a href="https://example.com/some-post">my post</a> and <a href="https://example.com/missing">this</a>.</p>`)
+	assert.NoError(t, err)
+	assert.Equal(t, out, htmlOut)
+
+}
+
+func TestInternalLinksResolverError(t *testing.T) {
+	expected := []Block{
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Content: &SpanContent{Text: "A "}},
+					{Type: "span", Content: &SpanContent{Text: "broken", Marks: []string{"mark1"}}},
+					{Type: "span", Content: &SpanContent{Text: " link."}},
+				},
+				MarkDefs: []MarkDef{
+					{Type: "link", Key: "mark1", Data: &LinkData{Href: "https://example.com/broken"}},
+				},
+			},
+		},
+	}
+
+	mc := NewMarkdownConverter(WithLinkResolver(testLinkResolver))
+	out, err := mc.ToBlocks("A [broken](https://example.com/broken) link.")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+
+	hc := NewHTMLConverter(WithHTMLLinkResolver(testLinkResolver))
+	out, err = hc.ToBlocks(`<p>A <a href="https://example.com/broken">broken</a> link.</p>`)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+}
+
+func TestRenderInternalLinks(t *testing.T) {
+	mc := NewMarkdownConverter(WithLinkResolver(testLinkResolver))
+	blocks, err := mc.ToBlocks("See [my post](https://example.com/some-post).")
+	assert.NoError(t, err)
+
+	linkURL := func(ref Reference) string {
+		return "https://example.com/posts/" + strings.TrimPrefix(string(ref), "post-")
+	}
+
+	assert.Equal(t, "See my post.", ToMarkdown(blocks))
+	assert.Equal(t, "See [my post](https://example.com/posts/123).", ToMarkdown(blocks, WithInternalLinkURL(linkURL)))
+
+	assert.Equal(t, "<p>See my post.</p>\n", NewHTMLRenderer().ToHTML(blocks))
+	assert.Equal(t, "<p>See <a href=\"https://example.com/posts/123\">my post</a>.</p>\n",
+		NewHTMLRenderer(WithHTMLInternalLinkURL(linkURL)).ToHTML(blocks))
+}
+
+func TestSanityLinkResolverSlug(t *testing.T) {
+	r := &SanityLinkResolver{BaseURL: "https://example.com/blog"}
+
+	cases := []struct {
+		href string
+		slug string
+	}{
+		{"https://example.com/blog/2020-05-01-some-post", "2020-05-01-some-post"},
+		{"https://EXAMPLE.com/blog/some-post/", "some-post"},
+		{"/blog/some-post", "some-post"},
+		{"https://example.com/other/some-post", ""},
+		{"https://example.com/blog/", ""},
+		{"https://other.com/blog/some-post", ""},
+		{"some-post", ""},
+		{"mailto:someone@example.com", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.href, func(t *testing.T) {
+			slug, ok := r.slug(c.href)
+			assert.Equal(t, c.slug, slug)
+			assert.Equal(t, c.slug != "", ok)
+		})
+	}
+}
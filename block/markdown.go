@@ -1,26 +1,67 @@
 package block
 
 import (
+	"context"
 	"fmt"
+	"html"
 	"strings"
 
 	"github.com/russross/blackfriday/v2"
 )
 
 type MarkdownConverter struct {
-	rules []MarkdownRuleFunc
+	rules         []MarkdownRuleFunc
+	imageResolver ImageResolver
+	linkResolver  LinkResolver
+
+	decoratorSyntax *DecoratorSyntax
+	typography      *Typography
+	strictBlocks    bool
+	parser          MarkdownParser
 }
 
 func NewMarkdownConverter(opts ...MarkdownOption) *MarkdownConverter {
-	mc := &MarkdownConverter{}
+	mc := &MarkdownConverter{
+		parser: BlackfridayParser,
+	}
 	for _, o := range opts {
 		o.Apply(mc)
 	}
 	return mc
 }
 
-func (mc *MarkdownConverter) newMarkdown() *blackfriday.Markdown {
-	return blackfriday.New(blackfriday.WithExtensions(blackfriday.CommonExtensions))
+// MarkdownParser parses the Markdown that a MarkdownConverter converts.
+type MarkdownParser interface {
+	Parse(source []byte) *MarkdownTree
+}
+
+// MarkdownTree is a parsed Markdown document. Rules are written against blackfriday's node types,
+// so every parser builds its tree out of them, and keeps what the parser knows about the nodes
+// that blackfriday has no place for alongside it.
+type MarkdownTree struct {
+	Root *blackfriday.Node
+	// ListStarts has the number that each ordered list starts at, if it isn't 1.
+	ListStarts map[*blackfriday.Node]int
+}
+
+type markdownParserFn func(source []byte) *MarkdownTree
+
+func (fn markdownParserFn) Parse(source []byte) *MarkdownTree {
+	return fn(source)
+}
+
+// BlackfridayParser is the default parser. It doesn't follow CommonMark exactly: among other
+// things, list items are indented by four spaces, and ordered lists always start at 1.
+var BlackfridayParser MarkdownParser = markdownParserFn(func(source []byte) *MarkdownTree {
+	md := blackfriday.New(blackfriday.WithExtensions(blackfriday.CommonExtensions | blackfriday.Footnotes))
+	return &MarkdownTree{Root: md.Parse(source)}
+})
+
+// WithMarkdownParser sets the parser that reads Markdown, such as GoldmarkParser.
+func WithMarkdownParser(p MarkdownParser) MarkdownOption {
+	return markdownOptionFn(func(mc *MarkdownConverter) {
+		mc.parser = p
+	})
 }
 
 type MarkdownRuleFunc func(b *Builder, node *blackfriday.Node, entering bool) (blackfriday.WalkStatus, bool)
@@ -41,110 +82,283 @@ func WithMarkdownRules(rules ...MarkdownRuleFunc) MarkdownOption {
 	})
 }
 
+// WithStrictBlocks makes ToBlocks return BuildErrors for text that ends up outside of any block,
+// usually because a rule added a custom block in the middle of a paragraph. Without it, that text
+// is put into a new normal block.
+func WithStrictBlocks() MarkdownOption {
+	return markdownOptionFn(func(mc *MarkdownConverter) {
+		mc.strictBlocks = true
+	})
+}
+
 func (mc *MarkdownConverter) ToBlocks(s string) ([]Block, error) {
-	root := mc.newMarkdown().Parse([]byte(s))
+	return mc.ToBlocksContext(context.Background(), s)
+}
 
-	var b Builder
+// ToBlocksContext converts Markdown to blocks, using ctx for any requests needed to resolve
+// images and links.
+func (mc *MarkdownConverter) ToBlocksContext(ctx context.Context, s string) ([]Block, error) {
+	tree := mc.parser.Parse([]byte(s))
+	root := tree.Root
 
-	var lastLinkKey string
-	root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
-		// if entering {
-		// 	fmt.Printf("entering %s\n", node.Type.String())
-		// } else {
-		// 	fmt.Printf("exiting %s\n", node.Type.String())
-		// }
+	w := &markdownWalker{
+		mc:         mc,
+		ctx:        ctx,
+		b:          &Builder{StrictBlocks: mc.strictBlocks},
+		listStarts: tree.ListStarts,
+	}
+	if mc.decoratorSyntax != nil {
+		w.decorators = mc.decoratorSyntax.apply(root)
+	}
+	if mc.typography != nil {
+		mc.typography.apply(root)
+	}
+	if err := w.walk(root); err != nil {
+		return nil, err
+	}
 
-		for _, rule := range mc.rules {
-			if result, ok := rule(&b, node, entering); ok {
-				return result
-			}
+	bs := w.b.Blocks()
+	if err := w.b.Err(); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+// markdownWalker holds the state for converting a single Markdown document.
+type markdownWalker struct {
+	mc  *MarkdownConverter
+	ctx context.Context
+	b   *Builder
+
+	// how many blockquotes we're inside
+	quote int
+	// whether a list item has started, but nothing has been added to its block yet
+	itemOpen bool
+
+	// the decorators for nodes added by DecoratorSyntax
+	decorators map[*blackfriday.Node]string
+	// the numbers that ordered lists start at, from the parser
+	listStarts map[*blackfriday.Node]int
+
+	// the mark key of the link that was started last, unless a rule took it over
+	lastLinkKey string
+	err         error
+}
+
+func (w *markdownWalker) walk(node *blackfriday.Node) error {
+	node.Walk(w.visit)
+	return w.err
+}
+
+func (w *markdownWalker) visit(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+	mc, b := w.mc, w.b
+
+	for _, rule := range mc.rules {
+		if result, ok := rule(b, node, entering); ok {
+			return result
 		}
+	}
 
-		switch node.Type {
-		case blackfriday.Document:
-			break
-		case blackfriday.Paragraph:
-			if node.Parent != nil &&
-				(node.Parent.Type == blackfriday.Item || node.Parent.Type == blackfriday.BlockQuote) {
-				if entering && node.Prev != nil {
-					b.AppendText("\n\n")
-				}
-				break
-			}
+	switch node.Type {
+	case blackfriday.Document:
+		break
+	case blackfriday.Paragraph:
+		if entering {
+			w.startTextBlock(w.paragraphStyle())
+		} else {
+			b.EndBlock()
+		}
+	case blackfriday.Heading:
+		if entering {
+			w.startTextBlock(fmt.Sprintf("h%d", node.Level))
+		} else {
+			b.EndBlock()
+		}
+	case blackfriday.Text:
+		text := strings.ReplaceAll(textLiteral(node), "\n", " ")
+		b.AppendText(text)
+	case blackfriday.Hardbreak:
+		b.AppendText("\n")
+	case blackfriday.Emph:
+		mark := "em"
+		if d, ok := w.decorators[node]; ok {
+			mark = d
+		}
+		if entering {
+			b.StartMark(mark)
+		} else {
+			b.EndMark(mark)
+		}
+	case blackfriday.Strong:
+		if entering {
+			b.StartMark("strong")
+		} else {
+			b.EndMark("strong")
+		}
+	case blackfriday.Del:
+		if entering {
+			b.StartMark("strike-through")
+		} else {
+			b.EndMark("strike-through")
+		}
+	case blackfriday.Code:
+		b.StartMark("code")
+		b.AppendText(string(node.Literal))
+		b.EndMark("code")
+	case blackfriday.List:
+		if node.IsFootnotesList {
+			// footnote content is converted along with the reference to it
+			return blackfriday.SkipChildren
+		}
 
-			if entering {
-				b.StartBlock("normal")
-			} else {
-				b.EndBlock()
-			}
-		case blackfriday.Heading:
-			if entering {
-				style := fmt.Sprintf("h%d", node.Level)
-				b.StartBlock(style)
-			} else {
-				b.EndBlock()
-			}
-		case blackfriday.Text:
-			text := strings.ReplaceAll(string(node.Literal), "\n", " ")
-			b.AppendText(text)
-		case blackfriday.Hardbreak:
-			b.AppendText("\n")
-		case blackfriday.Emph:
-			if entering {
-				b.StartMark("em")
-			} else {
-				b.EndMark("em")
-			}
-		case blackfriday.Strong:
-			if entering {
-				b.StartMark("strong")
-			} else {
-				b.EndMark("strong")
-			}
-		case blackfriday.Code:
-			b.StartMark("code")
-			b.AppendText(string(node.Literal))
-			b.EndMark("code")
-		case blackfriday.List:
-			if entering {
-				if node.ListFlags&blackfriday.ListTypeOrdered != 0 {
-					b.StartList("number")
-				} else {
-					b.StartList("bullet")
+		if entering {
+			if node.ListFlags&blackfriday.ListTypeOrdered != 0 {
+				start, ok := w.listStarts[node]
+				if !ok {
+					start = 1
 				}
+				b.StartNumberedList(start)
 			} else {
-				b.EndList()
-			}
-		case blackfriday.Item:
-			if entering {
-				b.StartListItem()
-			} else {
-				b.EndListItem()
+				b.StartList("bullet")
 			}
-		case blackfriday.BlockQuote:
-			if entering {
-				b.StartBlock("blockquote")
-			} else {
-				b.EndBlock()
+		} else {
+			b.EndList()
+		}
+	case blackfriday.Item:
+		if entering {
+			b.StartListItem()
+			if bc := b.currentText(); bc != nil {
+				bc.Style = w.paragraphStyle()
 			}
-		case blackfriday.CodeBlock:
-			b.AddCustomBlock("code", &CodeContent{
-				Language: string(node.Info),
-				Code:     strings.TrimSuffix(string(node.Literal), "\n"),
-			})
-		case blackfriday.Link:
+			w.itemOpen = true
+		} else {
+			b.EndListItem()
+			w.itemOpen = false
+		}
+	case blackfriday.BlockQuote:
+		if entering {
+			w.quote++
+		} else {
+			w.quote--
+			b.EndBlock()
+		}
+	case blackfriday.CodeBlock:
+		w.itemOpen = false
+		cc := ParseCodeInfo(string(node.Info))
+		cc.Indented = !node.IsFenced
+		cc.Code = strings.TrimSuffix(string(node.Literal), "\n")
+		b.AddCustomBlock(TypeCode, cc)
+	case blackfriday.Link:
+		if node.NoteID != 0 {
 			if entering {
-				lastLinkKey = b.AddMarkDef("link", &LinkData{
-					Href: string(node.Destination),
-				})
-				b.StartMark(lastLinkKey)
-			} else {
-				b.EndMark(lastLinkKey)
+				return w.addFootnote(node)
 			}
+			break
+		}
+
+		if entering {
+			key := addLink(w.ctx, b, mc.linkResolver, string(node.Destination))
+			w.lastLinkKey = key
+			b.StartMark(key)
+		} else if w.lastLinkKey != "" {
+			b.EndMark(w.lastLinkKey)
+			w.lastLinkKey = ""
+		}
+	case blackfriday.HorizontalRule:
+		b.AddCustomBlock(TypeBreak, &BreakContent{
+			Style: "lineBreak",
+		})
+	case blackfriday.Table:
+		if entering {
+			b.AddCustomBlock(TypeTable, tableFromNode(node))
+		}
+		return blackfriday.SkipChildren
+	case blackfriday.Image:
+		if mc.imageResolver == nil || !entering {
+			break
 		}
 
+		ref, err := mc.imageResolver.ResolveImage(w.ctx, string(node.Destination))
+		if err != nil {
+			w.err = err
+			return blackfriday.Terminate
+		}
+
+		b.InsertCustomBlock(TypeMainImage, &ImageContent{
+			Alt:     nodeText(node),
+			Caption: unescapeTitle(node.Title),
+			Asset:   ref,
+		})
+		return blackfriday.SkipChildren
+	}
+
+	return blackfriday.GoToNext
+}
+
+func (w *markdownWalker) paragraphStyle() string {
+	if w.quote > 0 {
+		return "blockquote"
+	}
+	return "normal"
+}
+
+// startTextBlock starts the block for a paragraph or heading. The first one in a list item
+// becomes the list item's own block, and any after that continue the list item.
+func (w *markdownWalker) startTextBlock(style string) {
+	if w.itemOpen {
+		w.itemOpen = false
+		if bc := w.b.currentText(); bc != nil {
+			bc.Style = style
+			return
+		}
+	}
+
+	w.b.StartListContinuation(style)
+}
+
+// nodeText collects the plain text inside a node, ignoring any formatting.
+func nodeText(node *blackfriday.Node) string {
+	var text []byte
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && n.Type == blackfriday.Text {
+			text = append(text, textLiteral(n)...)
+		} else if entering && n.Type == blackfriday.Code {
+			text = append(text, n.Literal...)
+		}
 		return blackfriday.GoToNext
 	})
+	return string(text)
+}
+
+// textLiteral is the text of a Text node. Blackfriday leaves character references in their own
+// nodes without resolving them, so they're resolved here like a CommonMark parser would.
+func textLiteral(node *blackfriday.Node) string {
+	text := string(node.Literal)
+	if len(text) > 2 && text[0] == '&' && text[len(text)-1] == ';' {
+		return html.UnescapeString(text)
+	}
+	return text
+}
+
+// unescapeTitle resolves backslash escapes and character references in a link title, which
+// blackfriday leaves as they were written.
+func unescapeTitle(title []byte) string {
+	var s strings.Builder
+	start := 0
+	for i := 0; i < len(title)-1; i++ {
+		if title[i] == '\\' && strings.IndexByte(asciiPunctuation, title[i+1]) >= 0 {
+			s.WriteString(resolveReferences(string(title[start:i])))
+			s.WriteByte(title[i+1])
+			i++
+			start = i + 1
+		}
+	}
+	s.WriteString(resolveReferences(string(title[start:])))
+	return s.String()
+}
+
+const asciiPunctuation = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
 
-	return b.Blocks(), nil
+func resolveReferences(s string) string {
+	return characterReference.ReplaceAllStringFunc(s, html.UnescapeString)
 }
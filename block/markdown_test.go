@@ -1,12 +1,23 @@
 package block
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// markdownParsers are the parsers that the Markdown tests are run with.
+var markdownParsers = []struct {
+	name   string
+	parser MarkdownParser
+}{
+	{"blackfriday", BlackfridayParser},
+	{"goldmark", GoldmarkParser},
+}
+
 func TestMarkdownToBlocks(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -436,7 +447,7 @@ Now there's a paragraph at the end.
 							{
 								Type: "span",
 								Content: &SpanContent{
-									Text: "This is a first paragraph\n\nThis should be part of the same list items",
+									Text: "This is a first paragraph",
 								},
 							},
 						},
@@ -445,6 +456,22 @@ Now there's a paragraph at the end.
 						MarkDefs: []MarkDef{},
 					},
 				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "This should be part of the same list items",
+								},
+							},
+						},
+						Level:    1,
+						MarkDefs: []MarkDef{},
+					},
+				},
 				{
 					Type: "block",
 					Content: &BlockContent{
@@ -529,7 +556,22 @@ A paragraph in-between
 							{
 								Type: "span",
 								Content: &SpanContent{
-									Text: "First quoted paragraph\n\nSecond quoted paragraph",
+									Text: "First quoted paragraph",
+								},
+							},
+						},
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "blockquote",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Second quoted paragraph",
 								},
 							},
 						},
@@ -565,7 +607,8 @@ A following paragraph`,
 				{
 					Type: "code",
 					Content: &CodeContent{
-						Code: "const foo = 1\nconst bar = 2",
+						Indented: true,
+						Code:     "const foo = 1\nconst bar = 2",
 					},
 				},
 				{
@@ -797,117 +840,704 @@ Links can be [done][] with footnotes too.
 				},
 			},
 		},
+		{
+			name:  "strikethrough",
+			input: "Some ~~old~~ text.",
+			output: []Block{
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Some ",
+								},
+							},
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text:  "old",
+									Marks: []string{"strike-through"},
+								},
+							},
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: " text.",
+								},
+							},
+						},
+						MarkDefs: []MarkDef{},
+					},
+				},
+			},
+		},
+		{
+			name:  "horizontal rule",
+			input: "Before\n\n---\n\nAfter",
+			output: []Block{
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Before",
+								},
+							},
+						},
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: "break",
+					Content: &BreakContent{
+						Style: "lineBreak",
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "After",
+								},
+							},
+						},
+						MarkDefs: []MarkDef{},
+					},
+				},
+			},
+		},
+		{
+			name:  "table",
+			input: "| Name | Value |\n| --- | --- |\n| *foo* | `1` |\n| bar | 2 |",
+			output: []Block{
+				{
+					Type: "table",
+					Content: &TableContent{
+						Rows: []TableRow{
+							{
+								Type:   "tableRow",
+								Header: true,
+								Cells:  []string{"Name", "Value"},
+							},
+							{
+								Type:  "tableRow",
+								Cells: []string{"foo", "1"},
+							},
+							{
+								Type:  "tableRow",
+								Cells: []string{"bar", "2"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "footnotes",
+			input: "Some text.[^note]\n\n[^note]: A *footnote*.\n\n    With two paragraphs.",
+			output: []Block{
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Some text.",
+								},
+							},
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text:  "1",
+									Marks: []string{"mark1"},
+								},
+							},
+						},
+						MarkDefs: []MarkDef{
+							{
+								Type: "footnote",
+								Key:  "mark1",
+								Data: &FootnoteData{
+									Number: 1,
+									Text: []Block{
+										{
+											Type: "block",
+											Content: &BlockContent{
+												Style: "normal",
+												Children: []Block{
+													{
+														Type: "span",
+														Content: &SpanContent{
+															Text: "A ",
+														},
+													},
+													{
+														Type: "span",
+														Content: &SpanContent{
+															Text:  "footnote",
+															Marks: []string{"em"},
+														},
+													},
+													{
+														Type: "span",
+														Content: &SpanContent{
+															Text: ".",
+														},
+													},
+												},
+												MarkDefs: []MarkDef{},
+											},
+										},
+										{
+											Type: "block",
+											Content: &BlockContent{
+												Style: "normal",
+												Children: []Block{
+													{
+														Type: "span",
+														Content: &SpanContent{
+															Text: "With two paragraphs.",
+														},
+													},
+												},
+												MarkDefs: []MarkDef{},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
-	mc := NewMarkdownConverter()
+	for _, p := range markdownParsers {
+		mc := NewMarkdownConverter(WithMarkdownParser(p.parser))
 
-	for _, c := range cases {
-		t.Run(c.name, func(t *testing.T) {
-			out, err := mc.ToBlocks(c.input)
-			assert.NoError(t, err)
-			assert.Equal(t, c.output, out)
-		})
+		for _, c := range cases {
+			t.Run(p.name+"/"+c.name, func(t *testing.T) {
+				out, err := mc.ToBlocks(c.input)
+				assert.NoError(t, err)
+				assert.Equal(t, c.output, out)
+			})
+		}
 	}
 }
 
-func TestMarkdownTweetRule(t *testing.T) {
-	mc := NewMarkdownConverter(WithMarkdownRules(TweetMarkdownRule))
-
-	out, err := mc.ToBlocks(`This is some content with an embedded tweet.
-
-https://twitter.com/some_user/status/1234567890
-
-And some more content afterwards.`)
-	assert.NoError(t, err)
+func TestMarkdownNestedStructure(t *testing.T) {
+	// in CommonMark, the content of "1. " is indented by 3 spaces instead of 4, which leaves a
+	// space at the start of the code block
+	headingAndCode := func(code string) []Block {
+		return []Block{
+			{
+				Type: "block",
+				Content: &BlockContent{
+					Style: "h2",
+					Children: []Block{
+						{
+							Type: "span",
+							Content: &SpanContent{
+								Text: "A heading",
+							},
+						},
+					},
+					ListItem: "number",
+					Level:    1,
+					MarkDefs: []MarkDef{},
+				},
+			},
+			{
+				Type: "code",
+				Content: &CodeContent{
+					Indented: true,
+					Code:     code,
+				},
+			},
+			{
+				Type: "block",
+				Content: &BlockContent{
+					Style: "normal",
+					Children: []Block{
+						{
+							Type: "span",
+							Content: &SpanContent{
+								Text: "After the code",
+							},
+						},
+					},
+					Level:    1,
+					MarkDefs: []MarkDef{},
+				},
+			},
+			{
+				Type: "block",
+				Content: &BlockContent{
+					Style: "normal",
+					Children: []Block{
+						{
+							Type: "span",
+							Content: &SpanContent{
+								Text: "Two",
+							},
+						},
+					},
+					ListItem: "number",
+					Level:    1,
+					MarkDefs: []MarkDef{},
+				},
+			},
+		}
+	}
 
-	assert.Equal(t, []Block{
+	cases := []struct {
+		name   string
+		input  string
+		output []Block
+		// parserOutputs replaces output for parsers that read the input differently
+		parserOutputs map[string][]Block
+	}{
 		{
-			Type: "block",
-			Content: &BlockContent{
-				Style: "normal",
-				Children: []Block{
-					{
-						Type: "span",
-						Content: &SpanContent{
-							Text: "This is some content with an embedded tweet.",
+			name:  "multi-paragraph quotes with a list",
+			input: "> Quoted list:\n>\n> - One\n> - Two\n>\n> After the list",
+			output: []Block{
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "blockquote",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Quoted list:",
+								},
+							},
 						},
+						MarkDefs: []MarkDef{},
 					},
 				},
-				MarkDefs: []MarkDef{},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "blockquote",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "One",
+								},
+							},
+						},
+						ListItem: "bullet",
+						Level:    1,
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "blockquote",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Two",
+								},
+							},
+						},
+						ListItem: "bullet",
+						Level:    1,
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "blockquote",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "After the list",
+								},
+							},
+						},
+						MarkDefs: []MarkDef{},
+					},
+				},
+			},
+		},
+		{
+			name:   "headings and code blocks in list items",
+			input:  "1. ## A heading\n\n        x := 1\n\n    After the code\n\n2. Two",
+			output: headingAndCode("x := 1"),
+			parserOutputs: map[string][]Block{
+				"goldmark": headingAndCode(" x := 1"),
 			},
 		},
 		{
-			Type: "tweet",
-			Content: &TweetContent{
-				URL: "https://twitter.com/some_user/status/1234567890",
+			name:  "continuation after a nested list",
+			input: "- One\n    - Nested\n\n    Back in one\n- Two",
+			output: []Block{
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "One",
+								},
+							},
+						},
+						ListItem: "bullet",
+						Level:    1,
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Nested",
+								},
+							},
+						},
+						ListItem: "bullet",
+						Level:    2,
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Back in one",
+								},
+							},
+						},
+						Level:    1,
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Two",
+								},
+							},
+						},
+						ListItem: "bullet",
+						Level:    1,
+						MarkDefs: []MarkDef{},
+					},
+				},
 			},
 		},
 		{
-			Type: "block",
-			Content: &BlockContent{
-				Style: "normal",
-				Children: []Block{
-					{
-						Type: "span",
-						Content: &SpanContent{
-							Text: "And some more content afterwards.",
+			name:  "quotes in list items",
+			input: "- An item\n\n    > Quoted in an item\n- Next",
+			output: []Block{
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "An item",
+								},
+							},
 						},
+						ListItem: "bullet",
+						Level:    1,
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "blockquote",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Quoted in an item",
+								},
+							},
+						},
+						Level:    1,
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Next",
+								},
+							},
+						},
+						ListItem: "bullet",
+						Level:    1,
+						MarkDefs: []MarkDef{},
 					},
 				},
-				MarkDefs: []MarkDef{},
 			},
 		},
-	}, out)
+	}
+
+	for _, p := range markdownParsers {
+		mc := NewMarkdownConverter(WithMarkdownParser(p.parser))
+
+		for _, c := range cases {
+			t.Run(p.name+"/"+c.name, func(t *testing.T) {
+				expected := c.output
+				if out, ok := c.parserOutputs[p.name]; ok {
+					expected = out
+				}
+				out, err := mc.ToBlocks(c.input)
+				assert.NoError(t, err)
+				assert.Equal(t, expected, out)
+			})
+		}
+	}
+}
+
+func TestMarkdownTweetRule(t *testing.T) {
+	for _, p := range markdownParsers {
+		t.Run(p.name, func(t *testing.T) {
+			mc := NewMarkdownConverter(WithMarkdownParser(p.parser), WithMarkdownRules(TweetMarkdownRule))
+
+			out, err := mc.ToBlocks(`This is some content with an embedded tweet.
+
+https://twitter.com/some_user/status/1234567890
+
+And some more content afterwards.`)
+			assert.NoError(t, err)
+
+			assert.Equal(t, []Block{
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "This is some content with an embedded tweet.",
+								},
+							},
+						},
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: "tweet",
+					Content: &TweetContent{
+						URL: "https://twitter.com/some_user/status/1234567890",
+						ID:  "1234567890",
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "And some more content afterwards.",
+								},
+							},
+						},
+						MarkDefs: []MarkDef{},
+					},
+				},
+			}, out)
+		})
+	}
 }
 
 func TestMarkdownYouTubeRule(t *testing.T) {
-	mc := NewMarkdownConverter(WithMarkdownRules(YouTubeMarkdownRule))
+	for _, p := range markdownParsers {
+		t.Run(p.name, func(t *testing.T) {
+			mc := NewMarkdownConverter(WithMarkdownParser(p.parser), WithMarkdownRules(YouTubeMarkdownRule))
 
-	out, err := mc.ToBlocks(`This is some content with an embedded YouTube video.
+			out, err := mc.ToBlocks(`This is some content with an embedded YouTube video.
 
 https://www.youtube.com/watch?v=TamwFUUd9Yk
 
 And some more content afterwards.`)
-	assert.NoError(t, err)
+			assert.NoError(t, err)
 
-	assert.Equal(t, []Block{
-		{
-			Type: "block",
-			Content: &BlockContent{
-				Style: "normal",
-				Children: []Block{
-					{
-						Type: "span",
-						Content: &SpanContent{
-							Text: "This is some content with an embedded YouTube video.",
+			assert.Equal(t, []Block{
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "This is some content with an embedded YouTube video.",
+								},
+							},
 						},
+						MarkDefs: []MarkDef{},
 					},
 				},
-				MarkDefs: []MarkDef{},
-			},
-		},
-		{
-			Type: "youtube",
-			Content: &YouTubeContent{
-				URL: "https://www.youtube.com/watch?v=TamwFUUd9Yk",
-			},
-		},
-		{
-			Type: "block",
-			Content: &BlockContent{
-				Style: "normal",
-				Children: []Block{
-					{
-						Type: "span",
-						Content: &SpanContent{
-							Text: "And some more content afterwards.",
+				{
+					Type: "youtube",
+					Content: &YouTubeContent{
+						URL: "https://www.youtube.com/watch?v=TamwFUUd9Yk",
+						ID:  "TamwFUUd9Yk",
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "And some more content afterwards.",
+								},
+							},
 						},
+						MarkDefs: []MarkDef{},
 					},
 				},
-				MarkDefs: []MarkDef{},
-			},
-		},
-	}, out)
+			}, out)
+		})
+	}
+}
+
+func TestMarkdownImages(t *testing.T) {
+	for _, p := range markdownParsers {
+		t.Run(p.name, func(t *testing.T) {
+			var resolved []string
+			mc := NewMarkdownConverter(WithMarkdownParser(p.parser), WithImageResolver(ImageResolverFunc(func(ctx context.Context, src string) (Reference, error) {
+				resolved = append(resolved, src)
+				return Reference("image-" + src[len(src)-3:]), nil
+			})))
+
+			out, err := mc.ToBlocks(`Before **the ![An image](https://example.com/abc "A caption") after**.
+
+![Just an image](https://example.com/def)`)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"https://example.com/abc", "https://example.com/def"}, resolved)
+
+			assert.Equal(t, []Block{
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: "Before ",
+								},
+							},
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text:  "the ",
+									Marks: []string{"strong"},
+								},
+							},
+						},
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: TypeMainImage,
+					Content: &ImageContent{
+						Alt:     "An image",
+						Caption: "A caption",
+						Asset:   "image-abc",
+					},
+				},
+				{
+					Type: "block",
+					Content: &BlockContent{
+						Style: "normal",
+						Children: []Block{
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text:  " after",
+									Marks: []string{"strong"},
+								},
+							},
+							{
+								Type: "span",
+								Content: &SpanContent{
+									Text: ".",
+								},
+							},
+						},
+						MarkDefs: []MarkDef{},
+					},
+				},
+				{
+					Type: TypeMainImage,
+					Content: &ImageContent{
+						Alt:   "Just an image",
+						Asset: "image-def",
+					},
+				},
+			}, out)
+		})
+	}
+}
+
+func TestMarkdownImageResolverError(t *testing.T) {
+	for _, p := range markdownParsers {
+		t.Run(p.name, func(t *testing.T) {
+			resolveErr := errors.New("upload failed")
+			mc := NewMarkdownConverter(WithMarkdownParser(p.parser), WithImageResolver(ImageResolverFunc(func(ctx context.Context, src string) (Reference, error) {
+				return "", resolveErr
+			})))
+
+			_, err := mc.ToBlocks("![An image](https://example.com/abc)")
+			assert.Equal(t, resolveErr, err)
+		})
+	}
 }
 
 func TestBlockJSONRoundTrip(t *testing.T) {
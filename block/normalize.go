@@ -0,0 +1,128 @@
+package block
+
+import (
+	"sort"
+)
+
+// Normalize cleans up the text blocks in a body: adjacent spans with the same marks are merged,
+// empty spans and unused mark definitions are dropped, and each span's marks are put in a
+// canonical order, with decorators sorted by name followed by annotations in the order of their
+// definitions. Footnote text is normalized too. The blocks passed in are not modified.
+func Normalize(blocks []Block) []Block {
+	if blocks == nil {
+		return nil
+	}
+
+	out := make([]Block, len(blocks))
+	for i, b := range blocks {
+		if bc, ok := b.Content.(*BlockContent); ok {
+			b.Content = normalizeText(bc)
+		}
+		out[i] = b
+	}
+	return out
+}
+
+func normalizeText(bc *BlockContent) *BlockContent {
+	nbc := *bc
+
+	annotations := make(map[string]int)
+	for i, md := range bc.MarkDefs {
+		annotations[md.Key] = i
+	}
+
+	nbc.Children = nil
+	var last *SpanContent
+	for _, child := range bc.Children {
+		sc, ok := child.Content.(*SpanContent)
+		if !ok {
+			nbc.Children = append(nbc.Children, child)
+			last = nil
+			continue
+		}
+		if sc.Text == "" {
+			continue
+		}
+
+		marks := canonicalMarks(sc.Marks, annotations)
+		if last != nil && equalMarks(last.Marks, marks) {
+			last.Text += sc.Text
+			continue
+		}
+
+		last = &SpanContent{Text: sc.Text, Marks: marks}
+		child.Content = last
+		nbc.Children = append(nbc.Children, child)
+	}
+	if len(nbc.Children) == 0 {
+		// a text block always needs at least one span, even if it's empty
+		nbc.Children = []Block{{Type: "span", Content: &SpanContent{Text: ""}}}
+	}
+
+	used := make(map[string]bool)
+	for _, child := range nbc.Children {
+		if sc, ok := child.Content.(*SpanContent); ok {
+			for _, mark := range sc.Marks {
+				used[mark] = true
+			}
+		}
+	}
+
+	nbc.MarkDefs = make([]MarkDef, 0, len(bc.MarkDefs))
+	for _, md := range bc.MarkDefs {
+		if !used[md.Key] {
+			continue
+		}
+		if fd, ok := md.Data.(*FootnoteData); ok {
+			nfd := *fd
+			nfd.Text = Normalize(fd.Text)
+			md.Data = &nfd
+		}
+		nbc.MarkDefs = append(nbc.MarkDefs, md)
+	}
+
+	return &nbc
+}
+
+// canonicalMarks sorts decorators by name before annotations, which keep the order of their mark
+// definitions. Duplicate marks are removed.
+func canonicalMarks(marks []string, annotations map[string]int) []string {
+	if len(marks) == 0 {
+		return marks
+	}
+
+	out := make([]string, 0, len(marks))
+	seen := make(map[string]bool)
+	for _, mark := range marks {
+		if !seen[mark] {
+			seen[mark] = true
+			out = append(out, mark)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		ai, iAnnotation := annotations[out[i]]
+		aj, jAnnotation := annotations[out[j]]
+		switch {
+		case iAnnotation && jAnnotation:
+			return ai < aj
+		case iAnnotation != jAnnotation:
+			return jAnnotation
+		default:
+			return out[i] < out[j]
+		}
+	})
+	return out
+}
+
+func equalMarks(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
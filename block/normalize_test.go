@@ -0,0 +1,91 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	in := []Block{
+		{
+			Type: "block",
+			Key:  "abc",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Key: "s1", Content: &SpanContent{Text: "Some ", Marks: []string{}}},
+					{Type: "span", Key: "s2", Content: &SpanContent{Text: "plain text. "}},
+					{Type: "span", Key: "s3", Content: &SpanContent{Text: ""}},
+					{Type: "span", Key: "s4", Content: &SpanContent{Text: "A ", Marks: []string{"l1", "strong", "em"}}},
+					{Type: "span", Key: "s5", Content: &SpanContent{Text: "link", Marks: []string{"em", "strong", "l1", "em"}}},
+					{Type: "span", Key: "s6", Content: &SpanContent{Text: "."}},
+				},
+				MarkDefs: []MarkDef{
+					{Type: "link", Key: "unused", Data: &LinkData{Href: "https://example.com/unused"}},
+					{Type: "link", Key: "l1", Data: &LinkData{Href: "https://example.com"}},
+				},
+			},
+		},
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Content: &SpanContent{Text: "", Marks: []string{"strong"}}},
+				},
+				MarkDefs: []MarkDef{},
+			},
+		},
+		{
+			Type:    TypeCode,
+			Content: &CodeContent{Code: "x := 1"},
+		},
+	}
+
+	assert.Equal(t, []Block{
+		{
+			Type: "block",
+			Key:  "abc",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Key: "s1", Content: &SpanContent{Text: "Some plain text. ", Marks: []string{}}},
+					{Type: "span", Key: "s4", Content: &SpanContent{Text: "A link", Marks: []string{"em", "strong", "l1"}}},
+					{Type: "span", Key: "s6", Content: &SpanContent{Text: "."}},
+				},
+				MarkDefs: []MarkDef{
+					{Type: "link", Key: "l1", Data: &LinkData{Href: "https://example.com"}},
+				},
+			},
+		},
+		{
+			Type: "block",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Content: &SpanContent{Text: ""}},
+				},
+				MarkDefs: []MarkDef{},
+			},
+		},
+		{
+			Type:    TypeCode,
+			Content: &CodeContent{Code: "x := 1"},
+		},
+	}, Normalize(in))
+
+	// the original blocks are left alone
+	assert.Len(t, in[0].Content.(*BlockContent).Children, 6)
+	assert.Len(t, in[0].Content.(*BlockContent).MarkDefs, 2)
+}
+
+func TestNormalizeFootnotes(t *testing.T) {
+	mc := NewMarkdownConverter()
+	blocks, err := mc.ToBlocks("Some text.[^1]\n\n[^1]: A **note**.")
+	assert.NoError(t, err)
+
+	out := Normalize(blocks)
+	assert.Equal(t, blocks, out)
+	assert.NoError(t, Validate(out))
+}
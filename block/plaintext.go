@@ -0,0 +1,280 @@
+package block
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PlainTexter is implemented by block content that has a plain-text form. ToPlainText includes it
+// when using WithCustomText.
+type PlainTexter interface {
+	PlainText() string
+}
+
+type PlainTextOption interface {
+	Apply(pt *plainTextWriter)
+}
+
+type plainTextOptionFn func(pt *plainTextWriter)
+
+func (fn plainTextOptionFn) Apply(pt *plainTextWriter) {
+	fn(pt)
+}
+
+// WithListMarkers prefixes list items with a "-" or their number, and puts items of the same list
+// on consecutive lines.
+func WithListMarkers() PlainTextOption {
+	return plainTextOptionFn(func(pt *plainTextWriter) {
+		pt.listMarkers = true
+	})
+}
+
+// WithCodeText includes the text of code blocks.
+func WithCodeText() PlainTextOption {
+	return plainTextOptionFn(func(pt *plainTextWriter) {
+		pt.codeText = true
+	})
+}
+
+// WithCustomText includes the text of custom blocks and inline objects whose content implements
+// PlainTexter.
+func WithCustomText() PlainTextOption {
+	return plainTextOptionFn(func(pt *plainTextWriter) {
+		pt.customText = true
+	})
+}
+
+// WithLinkURLs writes the URL of each link in parentheses after its text, unless the text is
+// already the URL.
+func WithLinkURLs() PlainTextOption {
+	return plainTextOptionFn(func(pt *plainTextWriter) {
+		pt.linkURLs = true
+	})
+}
+
+// WithMaxChars truncates the text to at most n characters, cutting at a word boundary if possible
+// and ending with an ellipsis.
+func WithMaxChars(n int) PlainTextOption {
+	return plainTextOptionFn(func(pt *plainTextWriter) {
+		pt.maxChars = n
+	})
+}
+
+// WithMaxWords truncates the text to at most n words, ending with an ellipsis.
+func WithMaxWords(n int) PlainTextOption {
+	return plainTextOptionFn(func(pt *plainTextWriter) {
+		pt.maxWords = n
+	})
+}
+
+const ellipsis = "…"
+
+type plainTextWriter struct {
+	listMarkers bool
+	codeText    bool
+	customText  bool
+	linkURLs    bool
+	maxChars    int
+	maxWords    int
+
+	out strings.Builder
+	// the next number for each level of the list we're currently in
+	listNumbers []int
+	prevList    bool
+
+	// the text of the current text block, and the link that the last span was in
+	text     strings.Builder
+	link     *MarkDef
+	linkText strings.Builder
+}
+
+// ToPlainText extracts the text of blocks, with a blank line between each block. By default only
+// text blocks are included, without any list markers.
+func ToPlainText(blocks []Block, opts ...PlainTextOption) string {
+	pt := &plainTextWriter{}
+	for _, o := range opts {
+		o.Apply(pt)
+	}
+
+	Walk(blocks, pt.visit)
+
+	out := pt.out.String()
+	if pt.maxWords > 0 {
+		out = truncateWords(out, pt.maxWords)
+	}
+	if pt.maxChars > 0 {
+		out = truncateChars(out, pt.maxChars)
+	}
+	return out
+}
+
+func (pt *plainTextWriter) visit(c *Cursor, entering bool) WalkStatus {
+	switch c.Kind() {
+	case BlockNode:
+		switch content := c.Block().Content.(type) {
+		case *BlockContent:
+			if entering {
+				pt.text.Reset()
+				return GoToNext
+			}
+			pt.endLink()
+
+			text := pt.text.String()
+			inList := content.Level > 0
+			if !inList {
+				pt.listNumbers = nil
+			}
+			if pt.listMarkers && inList {
+				text = listItemText(content, text, &pt.listNumbers)
+			}
+			pt.write(text, inList)
+		case *CodeContent:
+			if pt.codeText {
+				pt.write(content.Code, false)
+			}
+		case PlainTexter:
+			if pt.customText {
+				pt.write(content.PlainText(), false)
+			}
+		}
+	case ChildNode:
+		pt.child(c.TextBlock(), c.Block())
+	case MarkDefNode:
+		// footnote text isn't part of the plain text
+		return SkipChildren
+	}
+	return GoToNext
+}
+
+func (pt *plainTextWriter) write(text string, inList bool) {
+	if text == "" {
+		return
+	}
+	if pt.out.Len() > 0 {
+		if pt.listMarkers && pt.prevList && inList {
+			pt.out.WriteString("\n")
+		} else {
+			pt.out.WriteString("\n\n")
+		}
+	}
+	pt.out.WriteString(text)
+	pt.prevList = inList
+}
+
+func (pt *plainTextWriter) child(bc *BlockContent, child *Block) {
+	switch content := child.Content.(type) {
+	case *SpanContent:
+		if pt.linkURLs {
+			md := spanLink(bc, content)
+			if pt.link != nil && (md == nil || md.Key != pt.link.Key) {
+				pt.endLink()
+			}
+			if md != nil {
+				pt.link = md
+				pt.linkText.WriteString(content.Text)
+			}
+		}
+		pt.text.WriteString(content.Text)
+	case PlainTexter:
+		if pt.customText {
+			pt.endLink()
+			pt.text.WriteString(content.PlainText())
+		}
+	}
+}
+
+func (pt *plainTextWriter) endLink() {
+	if pt.link == nil {
+		return
+	}
+	var data LinkData
+	decodeContent(pt.link.Data, &data)
+	if data.Href != "" && data.Href != pt.linkText.String() {
+		fmt.Fprintf(&pt.text, " (%s)", data.Href)
+	}
+	pt.link = nil
+	pt.linkText.Reset()
+}
+
+// spanLink finds the link mark definition applied to a span, if any.
+func spanLink(bc *BlockContent, sc *SpanContent) *MarkDef {
+	for _, mark := range sc.Marks {
+		for i, md := range bc.MarkDefs {
+			if md.Key == mark && md.Type == "link" {
+				return &bc.MarkDefs[i]
+			}
+		}
+	}
+	return nil
+}
+
+func listItemText(bc *BlockContent, text string, listNumbers *[]int) string {
+	if len(*listNumbers) > bc.Level {
+		*listNumbers = (*listNumbers)[:bc.Level]
+	}
+	for len(*listNumbers) < bc.Level {
+		*listNumbers = append(*listNumbers, 1)
+	}
+	if bc.ListStart > 0 {
+		(*listNumbers)[bc.Level-1] = bc.ListStart
+	}
+
+	if bc.ListItem == "" {
+		return prefixLines(text, strings.Repeat("  ", bc.Level), true)
+	}
+
+	var marker string
+	if bc.ListItem == "number" {
+		marker = fmt.Sprintf("%d. ", (*listNumbers)[bc.Level-1])
+		(*listNumbers)[bc.Level-1]++
+	} else {
+		marker = "- "
+	}
+	return strings.Repeat("  ", bc.Level-1) + marker + text
+}
+
+func truncateWords(s string, n int) string {
+	inWord := false
+	words := 0
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			inWord = false
+			continue
+		}
+		if !inWord {
+			if words == n {
+				return strings.TrimRightFunc(s[:i], isTrailingExcerptRune) + ellipsis
+			}
+			words++
+			inWord = true
+		}
+	}
+	return s
+}
+
+func truncateChars(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	// leave room for the ellipsis
+	end := 0
+	for i := 0; i < n-1; i++ {
+		_, size := utf8.DecodeRuneInString(s[end:])
+		end += size
+	}
+
+	cut := s[:end]
+	if next, _ := utf8.DecodeRuneInString(s[end:]); !unicode.IsSpace(next) {
+		if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRightFunc(cut, isTrailingExcerptRune) + ellipsis
+}
+
+func isTrailingExcerptRune(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(",;:-", r)
+}
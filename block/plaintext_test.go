@@ -0,0 +1,88 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToPlainText(t *testing.T) {
+	mc := NewMarkdownConverter(WithMarkdownRules(TweetMarkdownRule))
+	blocks, err := mc.ToBlocks(`Read [the docs](https://example.com/docs) or https://example.com.
+
+- one
+- two
+    1. first
+    2. second
+
+https://twitter.com/some_user/status/1234567890
+
+    go build ./...
+
+Done.`)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		opts   []PlainTextOption
+		output string
+	}{
+		{
+			name: "default",
+			output: `Read the docs or https://example.com.
+
+one
+
+two
+
+first
+
+second
+
+Done.`,
+		},
+		{
+			name: "all content",
+			opts: []PlainTextOption{WithListMarkers(), WithCodeText(), WithCustomText(), WithLinkURLs()},
+			output: `Read the docs (https://example.com/docs) or https://example.com.
+
+- one
+- two
+  1. first
+  2. second
+
+https://twitter.com/some_user/status/1234567890
+
+go build ./...
+
+Done.`,
+		},
+		{
+			name:   "max words",
+			opts:   []PlainTextOption{WithMaxWords(3)},
+			output: "Read the docs…",
+		},
+		{
+			name:   "max chars at word boundary",
+			opts:   []PlainTextOption{WithMaxChars(17)},
+			output: "Read the docs or…",
+		},
+		{
+			name:   "max chars longer than text",
+			opts:   []PlainTextOption{WithMaxChars(1000), WithMaxWords(1000)},
+			output: ToPlainText(blocks),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.output, ToPlainText(blocks, c.opts...))
+		})
+	}
+}
+
+func TestTruncateChars(t *testing.T) {
+	assert.Equal(t, "Hello…", truncateChars("Hello, world", 10))
+	assert.Equal(t, "Supercalif…", truncateChars("Supercalifragilistic", 11))
+	assert.Equal(t, "héllo", truncateChars("héllo", 5))
+}
@@ -0,0 +1,39 @@
+package block
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrNotReference = errors.New("value is not a reference")
+
+// Reference is the ID of another document or asset, stored in Sanity's reference format. It's
+// defined here so blocks can be used without the client, which uses it as mpsanity.Reference.
+type Reference string
+
+func (r Reference) MarshalJSON() ([]byte, error) {
+	return json.Marshal(referenceJSON{
+		Type: "reference",
+		Ref:  string(r),
+	})
+}
+
+func (r *Reference) UnmarshalJSON(b []byte) error {
+	var ref referenceJSON
+	if err := json.Unmarshal(b, &ref); err != nil {
+		return err
+	}
+
+	if ref.Type != "reference" {
+		return fmt.Errorf("%s %w", ref.Type, ErrNotReference)
+	}
+
+	*r = Reference(ref.Ref)
+	return nil
+}
+
+type referenceJSON struct {
+	Type string `json:"_type"`
+	Ref  string `json:"_ref"`
+}
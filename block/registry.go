@@ -0,0 +1,89 @@
+package block
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	registryMu   sync.RWMutex
+	contentTypes = make(map[string]reflect.Type)
+	markDefTypes = make(map[string]reflect.Type)
+)
+
+func init() {
+	RegisterType("block", &BlockContent{})
+	RegisterType("span", &SpanContent{})
+	RegisterType(TypeCode, &CodeContent{})
+	RegisterType(TypeTweet, &TweetContent{})
+	RegisterType(TypeYouTube, &YouTubeContent{})
+	RegisterType(TypeVimeo, &EmbedContent{})
+	RegisterType(TypeInstagram, &EmbedContent{})
+	RegisterType(TypeMastodon, &EmbedContent{})
+	RegisterType(TypeGist, &EmbedContent{})
+	RegisterType(TypeCodePen, &EmbedContent{})
+	RegisterType(TypeSpotify, &EmbedContent{})
+	RegisterType(TypeBluesky, &EmbedContent{})
+	RegisterType(TypeMainImage, &ImageContent{})
+	RegisterType(TypeTable, &TableContent{})
+	RegisterType(TypeBreak, &BreakContent{})
+	RegisterType(TypeMention, &MentionContent{})
+
+	RegisterMarkDefType("link", &LinkData{})
+	RegisterMarkDefType(MarkFootnote, &FootnoteData{})
+	RegisterMarkDefType(MarkInternalLink, &InternalLinkData{})
+}
+
+// RegisterType sets the content type that blocks with the given _type are decoded into. The
+// content should be a pointer to a struct, like &CodeContent{}. Blocks of types that haven't been
+// registered are decoded into a map[string]interface{}. Fields that the content type doesn't have
+// are kept with the block and written back out when it's encoded.
+func RegisterType(typeName string, content interface{}) {
+	t := structType(content)
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	contentTypes[typeName] = t
+}
+
+// RegisterMarkDefType sets the type that mark definitions with the given _type are decoded into.
+// Like RegisterType, data should be a pointer to a struct.
+func RegisterMarkDefType(typeName string, data interface{}) {
+	t := structType(data)
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	markDefTypes[typeName] = t
+}
+
+func structType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("block: registered type must be a pointer to a struct, got %T", v))
+	}
+	return t.Elem()
+}
+
+// newContent returns a pointer to a new value of the content type registered for typeName.
+func newContent(typeName string) (interface{}, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	t, ok := contentTypes[typeName]
+	if !ok {
+		return nil, false
+	}
+	return reflect.New(t).Interface(), true
+}
+
+func newMarkDefData(typeName string) (interface{}, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	t, ok := markDefTypes[typeName]
+	if !ok {
+		return nil, false
+	}
+	return reflect.New(t).Interface(), true
+}
@@ -0,0 +1,105 @@
+package block
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type galleryContent struct {
+	Title  string   `json:"title"`
+	Images []string `json:"images"`
+}
+
+func TestUnmarshalRegisteredTypes(t *testing.T) {
+	RegisterType("gallery", &galleryContent{})
+
+	var blocks []Block
+	assert.NoError(t, json.Unmarshal([]byte(`[
+		{
+			"_type": "block",
+			"_key": "a",
+			"style": "normal",
+			"children": [{"_type": "span", "_key": "b", "text": "Hello", "marks": ["c"]}],
+			"markDefs": [{"_type": "link", "_key": "c", "href": "https://example.com"}]
+		},
+		{"_type": "code", "_key": "d", "language": "go", "code": "package main"},
+		{"_type": "tweet", "url": "https://twitter.com/some_user/status/1"},
+		{"_type": "youtube", "url": "https://www.youtube.com/watch?v=abc"},
+		{"_type": "gallery", "title": "Trip", "images": ["one", "two"]},
+		{"_type": "unknown", "_key": "e", "value": 1}
+	]`), &blocks))
+
+	assert.Equal(t, []Block{
+		{
+			Type: "block",
+			Key:  "a",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{
+						Type: "span",
+						Key:  "b",
+						Content: &SpanContent{
+							Text:  "Hello",
+							Marks: []string{"c"},
+						},
+					},
+				},
+				MarkDefs: []MarkDef{
+					{
+						Type: "link",
+						Key:  "c",
+						Data: &LinkData{Href: "https://example.com"},
+					},
+				},
+			},
+		},
+		{
+			Type:    TypeCode,
+			Key:     "d",
+			Content: &CodeContent{Language: "go", Code: "package main"},
+		},
+		{
+			Type:    TypeTweet,
+			Content: &TweetContent{URL: "https://twitter.com/some_user/status/1"},
+		},
+		{
+			Type:    TypeYouTube,
+			Content: &YouTubeContent{URL: "https://www.youtube.com/watch?v=abc"},
+		},
+		{
+			Type:    "gallery",
+			Content: &galleryContent{Title: "Trip", Images: []string{"one", "two"}},
+		},
+		{
+			Type:    "unknown",
+			Key:     "e",
+			Content: map[string]interface{}{"value": float64(1)},
+		},
+	}, blocks)
+}
+
+func TestRegisteredTypesKeepUnknownFields(t *testing.T) {
+	RegisterType("gallery", &galleryContent{})
+
+	input := `[
+		{"_type": "gallery", "_key": "a", "title": "Trip", "images": ["one"], "layout": {"columns": 2}},
+		{
+			"_type": "block",
+			"_key": "b",
+			"style": "normal",
+			"children": [{"_type": "span", "_key": "c", "text": "Hello", "marks": ["d"]}],
+			"markDefs": [{"_type": "link", "_key": "d", "href": "https://example.com", "blank": true}]
+		}
+	]`
+
+	var blocks []Block
+	assert.NoError(t, json.Unmarshal([]byte(input), &blocks))
+	assert.Equal(t, &galleryContent{Title: "Trip", Images: []string{"one"}}, blocks[0].Content)
+
+	out, err := json.Marshal(blocks)
+	assert.NoError(t, err)
+	assert.JSONEq(t, input, string(out))
+}
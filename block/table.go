@@ -0,0 +1,54 @@
+package block
+
+import (
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+const TypeTable = "table"
+
+type TableContent struct {
+	Rows []TableRow `json:"rows"`
+}
+
+type TableRow struct {
+	Type   string   `json:"_type"`
+	Header bool     `json:"header,omitempty"`
+	Cells  []string `json:"cells"`
+}
+
+// PlainText returns each row on its own line, with cells separated by tabs.
+func (c *TableContent) PlainText() string {
+	rows := make([]string, 0, len(c.Rows))
+	for _, row := range c.Rows {
+		rows = append(rows, strings.Join(row.Cells, "\t"))
+	}
+	return strings.Join(rows, "\n")
+}
+
+func tableFromNode(node *blackfriday.Node) *TableContent {
+	tc := &TableContent{}
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering {
+			return blackfriday.GoToNext
+		}
+
+		switch n.Type {
+		case blackfriday.TableRow:
+			tc.Rows = append(tc.Rows, TableRow{
+				Type:  "tableRow",
+				Cells: []string{},
+			})
+		case blackfriday.TableCell:
+			row := &tc.Rows[len(tc.Rows)-1]
+			row.Cells = append(row.Cells, nodeText(n))
+			if n.IsHeader {
+				row.Header = true
+			}
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+	return tc
+}
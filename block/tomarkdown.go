@@ -0,0 +1,674 @@
+package block
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// MarkdownTypeFunc renders a custom block type to Markdown.
+type MarkdownTypeFunc func(b Block) string
+
+type ToMarkdownOption interface {
+	Apply(ms *markdownSerializer)
+}
+
+type toMarkdownOptionFn func(ms *markdownSerializer)
+
+func (fn toMarkdownOptionFn) Apply(ms *markdownSerializer) {
+	fn(ms)
+}
+
+// WithMarkdownType sets how blocks of a custom type are rendered, replacing the built-in
+// rendering if there is one.
+func WithMarkdownType(typeName string, fn MarkdownTypeFunc) ToMarkdownOption {
+	return toMarkdownOptionFn(func(ms *markdownSerializer) {
+		ms.types[typeName] = fn
+	})
+}
+
+// WithMarkdownInline sets how inline objects of a custom type are written inside a block.
+// Inline objects without a MarkdownTypeFunc are left out.
+func WithMarkdownInline(typeName string, fn MarkdownTypeFunc) ToMarkdownOption {
+	return toMarkdownOptionFn(func(ms *markdownSerializer) {
+		ms.inline[typeName] = fn
+	})
+}
+
+// WithImageURL sets how the asset reference of a mainImage block is turned into an image URL.
+// By default, the asset ID is used as is.
+func WithImageURL(fn func(assetID string) string) ToMarkdownOption {
+	return toMarkdownOptionFn(func(ms *markdownSerializer) {
+		ms.imageURL = fn
+	})
+}
+
+// WithInternalLinkURL sets how the document reference of an internalLink is turned into a URL.
+// By default, internal links are written as plain text.
+func WithInternalLinkURL(fn func(ref Reference) string) ToMarkdownOption {
+	return toMarkdownOptionFn(func(ms *markdownSerializer) {
+		ms.internalLinkURL = fn
+	})
+}
+
+// WithMarkdownDecoratorSyntax writes the decorators in ds with the same inline syntax that
+// WithDecoratorSyntax parses. Without it, those decorators are left out of the Markdown.
+// Superscripts and subscripts that contain spaces can't be parsed back.
+func WithMarkdownDecoratorSyntax(ds DecoratorSyntax) ToMarkdownOption {
+	return toMarkdownOptionFn(func(ms *markdownSerializer) {
+		ms.decoratorDelims = make(map[string]string)
+		for _, delim := range []string{"==", "++", "^", "~"} {
+			if mark := ds.decorator(delim); mark != "" {
+				ms.decoratorDelims[mark] = delim
+			}
+		}
+	})
+}
+
+const listIndent = "    "
+
+type markdownSerializer struct {
+	types           map[string]MarkdownTypeFunc
+	inline          map[string]MarkdownTypeFunc
+	imageURL        func(assetID string) string
+	internalLinkURL func(ref Reference) string
+
+	// delimiters for decorators that aren't part of Markdown itself
+	decoratorDelims map[string]string
+
+	// footnotes referenced so far, written after the rest of the document
+	footnotes []FootnoteData
+}
+
+// ToMarkdown renders blocks as Markdown. Converting the result back with a MarkdownConverter
+// produces the same blocks, except that CommonMark parsers drop spaces at the start of a line
+// after a line break.
+func ToMarkdown(blocks []Block, opts ...ToMarkdownOption) string {
+	ms := &markdownSerializer{
+		types:  make(map[string]MarkdownTypeFunc),
+		inline: make(map[string]MarkdownTypeFunc),
+		imageURL: func(assetID string) string {
+			return assetID
+		},
+	}
+	ms.types[TypeCode] = codeToMarkdown
+	ms.types[TypeTweet] = embedToMarkdown
+	ms.types[TypeYouTube] = embedToMarkdown
+	ms.types[TypeVimeo] = embedToMarkdown
+	ms.types[TypeInstagram] = embedToMarkdown
+	ms.types[TypeMastodon] = embedToMarkdown
+	ms.types[TypeGist] = embedToMarkdown
+	ms.types[TypeCodePen] = embedToMarkdown
+	ms.types[TypeSpotify] = embedToMarkdown
+	ms.types[TypeBluesky] = embedToMarkdown
+	ms.types[TypeMainImage] = ms.imageToMarkdown
+	ms.types[TypeTable] = tableToMarkdown
+	ms.types[TypeBreak] = breakToMarkdown
+	ms.inline[TypeMention] = mentionToMarkdown
+
+	for _, o := range opts {
+		o.Apply(ms)
+	}
+
+	s := ms.serialize(blocks)
+
+	// footnotes can reference other footnotes, so the list may grow as we go
+	for i := 0; i < len(ms.footnotes); i++ {
+		fn := ms.footnotes[i]
+		text := ms.serialize(fn.Text)
+		s += fmt.Sprintf("\n\n[^%d]: %s", fn.Number, prefixLines(text, listIndent, false))
+	}
+
+	return s
+}
+
+func (ms *markdownSerializer) serialize(blocks []Block) string {
+	var s strings.Builder
+
+	// the next number for each level of the list we're currently in
+	var listNumbers []int
+
+	var prevList, prevQuote bool
+	// whether the list we're in is inside a blockquote, rather than having quotes inside it
+	var quotedList bool
+	for i, b := range blocks {
+		bc, isText := b.Content.(*BlockContent)
+		isItem := isText && bc.ListItem != "" && bc.Level > 0
+		// continuations are later paragraphs of a list item, indented to its level
+		isContinuation := isText && bc.ListItem == "" && bc.Level > 0
+		isQuote := isText && bc.Style == "blockquote"
+		if isItem {
+			quotedList = isQuote
+		}
+		if isContinuation && isQuote && !quotedList {
+			// the quote is part of the list item, so it's indented along with it
+			isQuote = false
+		}
+		// a custom block between parts of a list item is indented to stay inside it
+		var customLevel int
+		if !isText && prevList {
+			customLevel = continuationLevel(blocks, i+1)
+		}
+
+		if s.Len() > 0 {
+			switch {
+			case isItem && prevList:
+				s.WriteString("\n")
+			case isQuote && prevQuote:
+				s.WriteString("\n>\n")
+			default:
+				s.WriteString("\n\n")
+			}
+		}
+		prevList = isItem || isContinuation || customLevel > 0
+		prevQuote = isQuote
+
+		if !prevList {
+			listNumbers = nil
+		}
+
+		if !isText {
+			fn, ok := ms.types[b.Type]
+			if !ok {
+				continue
+			}
+
+			if customLevel > 0 {
+				s.WriteString(prefixLines(fn(b), strings.Repeat(listIndent, customLevel), true))
+			} else {
+				s.WriteString(fn(b))
+			}
+			continue
+		}
+
+		text := ms.spansToMarkdown(bc)
+		if len(bc.Style) == 2 && bc.Style[0] == 'h' && bc.Style[1] >= '1' && bc.Style[1] <= '6' {
+			text = strings.Repeat("#", int(bc.Style[1]-'0')) + " " + text
+		}
+
+		var out strings.Builder
+		switch {
+		case isItem:
+			if len(listNumbers) > bc.Level {
+				listNumbers = listNumbers[:bc.Level]
+			}
+			for len(listNumbers) < bc.Level {
+				listNumbers = append(listNumbers, 1)
+			}
+			if bc.ListStart > 0 {
+				listNumbers[bc.Level-1] = bc.ListStart
+			}
+
+			// four spaces per level nests reliably no matter how wide the list markers are
+			indent := strings.Repeat(listIndent, bc.Level-1)
+			out.WriteString(indent)
+			if bc.ListItem == "number" {
+				fmt.Fprintf(&out, "%d. ", listNumbers[bc.Level-1])
+				listNumbers[bc.Level-1]++
+			} else {
+				out.WriteString("- ")
+			}
+			out.WriteString(prefixLines(text, indent+listIndent, false))
+		case isContinuation:
+			if len(listNumbers) > bc.Level {
+				listNumbers = listNumbers[:bc.Level]
+			}
+			if bc.Style == "blockquote" && !isQuote {
+				text = prefixLines(text, "> ", true)
+			}
+			out.WriteString(prefixLines(text, strings.Repeat(listIndent, bc.Level), true))
+		default:
+			out.WriteString(text)
+		}
+
+		if isQuote {
+			s.WriteString(prefixLines(out.String(), "> ", true))
+		} else {
+			s.WriteString(out.String())
+		}
+	}
+
+	return s.String()
+}
+
+// continuationLevel returns the level of the list item that the next text block from i
+// continues, or 0 if it doesn't continue one.
+func continuationLevel(blocks []Block, i int) int {
+	for ; i < len(blocks); i++ {
+		if bc, ok := blocks[i].Content.(*BlockContent); ok {
+			if bc.ListItem == "" {
+				return bc.Level
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// prefixLines adds a prefix to every line of text after the first, and to the first as well if
+// includeFirst is set. Blank lines get the prefix without trailing whitespace.
+func prefixLines(text string, prefix string, includeFirst bool) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if i == 0 && !includeFirst {
+			continue
+		}
+		if line == "" {
+			lines[i] = strings.TrimRight(prefix, " ")
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (ms *markdownSerializer) spansToMarkdown(bc *BlockContent) string {
+	markDefs := make(map[string]MarkDef)
+	for _, md := range bc.MarkDefs {
+		markDefs[md.Key] = md
+	}
+
+	var s strings.Builder
+	var open []string
+	var pendingSpace string
+
+	closeMark := func(mark string) {
+		switch mark {
+		case "em":
+			s.WriteString("_")
+		case "strong":
+			s.WriteString("**")
+		case "strike-through":
+			s.WriteString("~~")
+		case "code":
+		default:
+			if delim, ok := ms.decoratorDelims[mark]; ok {
+				s.WriteString(delim)
+			} else if href, ok := ms.markHref(markDefs, mark); ok {
+				fmt.Fprintf(&s, "](%s)", href)
+			}
+		}
+	}
+	openMark := func(mark string) {
+		switch mark {
+		case "em":
+			s.WriteString("_")
+		case "strong":
+			s.WriteString("**")
+		case "strike-through":
+			s.WriteString("~~")
+		case "code":
+		default:
+			if delim, ok := ms.decoratorDelims[mark]; ok {
+				s.WriteString(delim)
+			} else if _, ok := ms.markHref(markDefs, mark); ok {
+				s.WriteString("[")
+			}
+		}
+	}
+
+	for _, child := range bc.Children {
+		sc, ok := child.Content.(*SpanContent)
+		if !ok {
+			if fn, ok := ms.inline[child.Type]; ok {
+				s.WriteString(pendingSpace)
+				pendingSpace = ""
+				s.WriteString(fn(child))
+			}
+			continue
+		}
+
+		// close any marks this span doesn't have, and everything opened after them
+		keep := 0
+		for keep < len(open) && hasMark(sc.Marks, open[keep]) {
+			keep++
+		}
+		for i := len(open) - 1; i >= keep; i-- {
+			closeMark(open[i])
+		}
+		open = open[:keep]
+
+		text := sc.Text
+		trimmed := strings.TrimLeft(text, " \t")
+		s.WriteString(pendingSpace)
+		s.WriteString(text[:len(text)-len(trimmed)])
+		text = trimmed
+		trimmed = strings.TrimRight(text, " \t")
+		pendingSpace = text[len(trimmed):]
+		text = trimmed
+
+		// open new marks in the order they appear, with code always innermost
+		for _, mark := range orderedMarks(sc.Marks, ms.isDecorator) {
+			if mark != "code" && !hasMark(open, mark) {
+				openMark(mark)
+				open = append(open, mark)
+			}
+		}
+
+		if fn, ok := footnoteMark(sc.Marks, markDefs); ok {
+			if fn.Number == 0 {
+				fn.Number = len(ms.footnotes) + 1
+			}
+			if !ms.hasFootnote(fn.Number) {
+				ms.footnotes = append(ms.footnotes, fn)
+			}
+			fmt.Fprintf(&s, "[^%d]", fn.Number)
+		} else if hasMark(sc.Marks, "code") {
+			s.WriteString(codeSpan(text))
+		} else {
+			out := s.String()
+			lineStart := strings.TrimLeft(out[strings.LastIndex(out, "\n")+1:], " \t") == ""
+			s.WriteString(escapeMarkdown(text, lineStart))
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		closeMark(open[i])
+	}
+	s.WriteString(pendingSpace)
+
+	return s.String()
+}
+
+// hasFootnote reports whether a footnote with the given number will already be written.
+func (ms *markdownSerializer) hasFootnote(number int) bool {
+	for _, fn := range ms.footnotes {
+		if fn.Number == number {
+			return true
+		}
+	}
+	return false
+}
+
+// footnoteMark finds the footnote annotation among a span's marks, if it has one.
+func footnoteMark(marks []string, markDefs map[string]MarkDef) (FootnoteData, bool) {
+	for _, m := range marks {
+		if md, ok := markDefs[m]; ok && md.Type == MarkFootnote {
+			var fn FootnoteData
+			decodeContent(md.Data, &fn)
+			return fn, true
+		}
+	}
+	return FootnoteData{}, false
+}
+
+func hasMark(marks []string, mark string) bool {
+	for _, m := range marks {
+		if m == mark {
+			return true
+		}
+	}
+	return false
+}
+
+// orderedMarks puts annotations before decorators, so links wrap any formatting inside them.
+func orderedMarks(marks []string, isDecorator func(mark string) bool) []string {
+	var out []string
+	for _, m := range marks {
+		if !isDecorator(m) {
+			out = append(out, m)
+		}
+	}
+	for _, m := range marks {
+		if isDecorator(m) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (ms *markdownSerializer) isDecorator(mark string) bool {
+	_, ok := ms.decoratorDelims[mark]
+	return ok || isMarkdownDecorator(mark)
+}
+
+func isMarkdownDecorator(mark string) bool {
+	switch mark {
+	case "em", "strong", "strike-through", "code":
+		return true
+	}
+	return false
+}
+
+// markHref returns the URL a mark links to, if it's a link or an internal link that can be
+// turned into a URL.
+func (ms *markdownSerializer) markHref(markDefs map[string]MarkDef, mark string) (string, bool) {
+	md, ok := markDefs[mark]
+	if !ok {
+		return "", false
+	}
+
+	var href string
+	switch md.Type {
+	case "link":
+		var link LinkData
+		decodeContent(md.Data, &link)
+		href = link.Href
+	case MarkInternalLink:
+		if ms.internalLinkURL == nil {
+			return "", false
+		}
+		var link InternalLinkData
+		decodeContent(md.Data, &link)
+		href = ms.internalLinkURL(link.Reference)
+	default:
+		return "", false
+	}
+	return linkDestination(href), true
+}
+
+var destinationEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"(", `\(`,
+	")", `\)`,
+	"'", `\'`,
+	`"`, `\"`,
+	// angle brackets aren't valid in URLs, and a trailing one is taken as the end of the destination
+	"<", "%3C",
+	">", "%3E",
+	"\n", "%0A",
+)
+
+// linkDestination writes href so that parsing it gives back the same href. Hrefs with spaces are
+// put in angle brackets. Angle brackets and line breaks, which can't be in a URL anyway, are
+// percent-encoded.
+func linkDestination(href string) string {
+	dest := destinationEscaper.Replace(href)
+	if strings.ContainsAny(dest, " \t") {
+		return "<" + dest + ">"
+	}
+	return dest
+}
+
+var titleEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	`(`, `\(`,
+	`)`, `\)`,
+)
+
+// linkTitle quotes title so that parsing it gives back the same title.
+func linkTitle(title string) string {
+	return `"` + characterReference.ReplaceAllString(titleEscaper.Replace(title), `\$0`) + `"`
+}
+
+func codeSpan(text string) string {
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`~`, `\~`,
+	"\n", "\\\n",
+)
+
+// characterReference matches text that Markdown parsers would read as an HTML entity.
+var characterReference = regexp.MustCompile(`&#?[0-9A-Za-z]+;`)
+
+// escapeText escapes the characters in text that could be read as inline Markdown syntax.
+func escapeText(text string) string {
+	return characterReference.ReplaceAllString(markdownEscaper.Replace(text), `\$0`)
+}
+
+// escapeMarkdown escapes text so it's read back as the same text. Line breaks become hard
+// breaks, and each line that begins a new line of output has any block syntax escaped.
+func escapeMarkdown(text string, lineStart bool) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = escapeText(line)
+		if i > 0 || lineStart {
+			line = escapeLineStart(line)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\\\n")
+}
+
+func escapeLineStart(line string) string {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	prefix, text := line[:indent], line[indent:]
+
+	switch {
+	case strings.HasPrefix(text, "#"), strings.HasPrefix(text, ">"),
+		strings.HasPrefix(text, "- "), strings.HasPrefix(text, "+ "):
+		return prefix + `\` + text
+	case isRepeated(text, '-'):
+		// a thematic break, or a setext heading underline after a hard break
+		return prefix + `\` + text
+	case isRepeated(text, '='):
+		// = can't be backslash escaped in every parser, but a character reference can
+		return prefix + "&#61;" + text[1:]
+	}
+
+	digits := 0
+	for digits < len(text) && text[digits] >= '0' && text[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && digits < len(text) && (text[digits] == '.' || text[digits] == ')') {
+		return prefix + text[:digits] + `\` + text[digits:]
+	}
+
+	return line
+}
+
+// isRepeated reports whether text is only c, ignoring trailing spaces.
+func isRepeated(text string, c byte) bool {
+	text = strings.TrimRight(text, " \t")
+	return text != "" && strings.Trim(text, string(c)) == ""
+}
+
+func codeToMarkdown(b Block) string {
+	var cc CodeContent
+	decodeContent(b.Content, &cc)
+
+	fence := "```"
+	for strings.Contains(cc.Code, fence) {
+		fence += "`"
+	}
+	return fence + cc.Info() + "\n" + cc.Code + "\n" + fence
+}
+
+func tableToMarkdown(b Block) string {
+	var tc TableContent
+	decodeContent(b.Content, &tc)
+
+	rows := tc.Rows
+	columns := 0
+	for _, row := range rows {
+		if len(row.Cells) > columns {
+			columns = len(row.Cells)
+		}
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	// Markdown tables always have a header row, so add an empty one if the table doesn't
+	var header TableRow
+	if len(rows) > 0 && rows[0].Header {
+		header, rows = rows[0], rows[1:]
+	}
+
+	var s strings.Builder
+	writeRow := func(cells []string) {
+		s.WriteString("|")
+		for i := 0; i < columns; i++ {
+			var cell string
+			if i < len(cells) {
+				cell = tableCellEscaper.Replace(escapeText(cells[i]))
+			}
+			s.WriteString(" " + cell + " |")
+		}
+	}
+
+	writeRow(header.Cells)
+	s.WriteString("\n|")
+	s.WriteString(strings.Repeat(" --- |", columns))
+	for _, row := range rows {
+		s.WriteString("\n")
+		writeRow(row.Cells)
+	}
+	return s.String()
+}
+
+var tableCellEscaper = strings.NewReplacer(
+	"|", `\|`,
+	"\\\n", " ",
+	"\n", " ",
+)
+
+func breakToMarkdown(Block) string {
+	return "---"
+}
+
+func embedToMarkdown(b Block) string {
+	var content EmbedContent
+	decodeContent(b.Content, &content)
+	return content.URL
+}
+
+func (ms *markdownSerializer) imageToMarkdown(b Block) string {
+	var ic ImageContent
+	decodeContent(b.Content, &ic)
+
+	src := linkDestination(ms.imageURL(string(ic.Asset)))
+	if ic.Caption != "" {
+		return fmt.Sprintf("![%s](%s %s)", escapeText(ic.Alt), src, linkTitle(ic.Caption))
+	}
+	return fmt.Sprintf("![%s](%s)", escapeText(ic.Alt), src)
+}
+
+// decodeContent copies block content into out, whether the content is already the right type or
+// a generic map from decoding JSON.
+func decodeContent(content interface{}, out interface{}) bool {
+	if content == nil {
+		return false
+	}
+
+	outVal := reflect.ValueOf(out)
+	if cv := reflect.ValueOf(content); cv.Type() == outVal.Type() {
+		outVal.Elem().Set(cv.Elem())
+		return true
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, out) == nil
+}
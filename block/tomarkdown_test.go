@@ -0,0 +1,320 @@
+package block
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToMarkdownRoundTrip(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{
+			name:   "simple paragraph",
+			input:  "This is some text.",
+			output: "This is some text.",
+		},
+		{
+			name:   "formatting",
+			input:  "This is *some text* with __formatting__. Including `code.`",
+			output: "This is _some text_ with **formatting**. Including `code.`",
+		},
+		{
+			name:   "nested formatting",
+			input:  "Some **bold _and italic_ text**.",
+			output: "Some **bold _and italic_ text**.",
+		},
+		{
+			name:   "multiple paragraphs",
+			input:  "This is some text.\n\nAnd this is some more text.",
+			output: "This is some text.\n\nAnd this is some more text.",
+		},
+		{
+			name:   "line breaks",
+			input:  "This is some text.  \nOn two lines.",
+			output: "This is some text.\\\nOn two lines.",
+		},
+		{
+			name:   "headings",
+			input:  "# Heading 1\n\nSome text.\n\n### Heading 3",
+			output: "# Heading 1\n\nSome text.\n\n### Heading 3",
+		},
+		{
+			name:   "nested lists",
+			input:  "* One\n* Two\n    * Nested\n        1. Deeper\n        2. Deeper still\n* Three\n\nAfter.",
+			output: "- One\n- Two\n    - Nested\n        1. Deeper\n        2. Deeper still\n- Three\n\nAfter.",
+		},
+		{
+			name:   "ordered lists",
+			input:  "1. First\n2. Second\n    1. Nested\n3. Third",
+			output: "1. First\n2. Second\n    1. Nested\n3. Third",
+		},
+		{
+			name:   "lists with paragraphs",
+			input:  "* One\n\n    Continued\n* Two",
+			output: "- One\n\n    Continued\n- Two",
+		},
+		{
+			name:   "blockquotes",
+			input:  "> This is a quote.\n>\n> With two paragraphs.",
+			output: "> This is a quote.\n>\n> With two paragraphs.",
+		},
+		{
+			name:   "links",
+			input:  "A [link with **bold**](https://example.com/foo) in it.",
+			output: "A [link with **bold**](https://example.com/foo) in it.",
+		},
+		{
+			name:   "code blocks",
+			input:  "```go\nfunc main() {}\n```",
+			output: "```go\nfunc main() {}\n```",
+		},
+		{
+			name:   "special characters",
+			input:  "1\\. Not a list, \\*not emphasis\\* and a snake\\_case [bracket].",
+			output: "1\\. Not a list, \\*not emphasis\\* and a snake\\_case \\[bracket\\].",
+		},
+		{
+			name:   "strikethrough",
+			input:  "Some ~~old~~ text, and a lone \\~ tilde.",
+			output: "Some ~~old~~ text, and a lone \\~ tilde.",
+		},
+		{
+			name:   "horizontal rules",
+			input:  "Before\n\n***\n\nAfter",
+			output: "Before\n\n---\n\nAfter",
+		},
+		{
+			name:   "tables",
+			input:  "Name | Value\n---|---\nfoo | 1\nbar\\|baz | 2",
+			output: "| Name | Value |\n| --- | --- |\n| foo | 1 |\n| bar\\|baz | 2 |",
+		},
+		{
+			name:   "footnotes",
+			input:  "Some text.[^a] More.[^b]\n\n[^a]: First.\n\n    Continued.\n\n[^b]: Second.",
+			output: "Some text.[^1] More.[^2]\n\n[^1]: First.\n\n    Continued.\n\n[^2]: Second.",
+		},
+		{
+			name:   "lists in blockquotes",
+			input:  "> Quoted list:\n>\n> * One\n>\n>     Continued\n> * Two\n>\n> After the list",
+			output: "> Quoted list:\n>\n> - One\n>\n>     Continued\n> - Two\n>\n> After the list",
+		},
+		{
+			name:   "nested content in list items",
+			input:  "1. ## A heading\n    1. Nested\n\n    Back in one\n\n    > Quoted\n2. Two",
+			output: "1. ## A heading\n    1. Nested\n\n    Back in one\n\n    > Quoted\n2. Two",
+		},
+	}
+
+	mc := NewMarkdownConverter()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blocks, err := mc.ToBlocks(c.input)
+			assert.NoError(t, err)
+
+			out := ToMarkdown(blocks)
+			assert.Equal(t, c.output, out)
+
+			again, err := mc.ToBlocks(out)
+			assert.NoError(t, err)
+			assert.Equal(t, blocks, again)
+		})
+	}
+}
+
+func TestToMarkdownCustomTypes(t *testing.T) {
+	blocks := []Block{
+		New("normal", Text("Some text.")),
+		{
+			Type:    TypeTweet,
+			Content: &TweetContent{URL: "https://twitter.com/some_user/status/1234567890"},
+		},
+		{
+			Type: "mainImage",
+			Content: map[string]interface{}{
+				"alt": "Photo",
+				"asset": map[string]interface{}{
+					"_type": "reference",
+					"_ref":  "image-abc-100x100-jpg",
+				},
+			},
+		},
+		{
+			Type: "gallery",
+			Content: map[string]interface{}{
+				"title": "Vacation",
+			},
+		},
+	}
+
+	out := ToMarkdown(blocks,
+		WithImageURL(func(assetID string) string {
+			return "https://cdn.example.com/" + assetID
+		}),
+		WithMarkdownType("gallery", func(b Block) string {
+			return "[gallery: " + b.Content.(map[string]interface{})["title"].(string) + "]"
+		}))
+
+	assert.Equal(t, `Some text.
+
+https://twitter.com/some_user/status/1234567890
+
+![Photo](https://cdn.example.com/image-abc-100x100-jpg)
+
+[gallery: Vacation]`, out)
+}
+
+func TestToMarkdownFootnoteReferencedTwice(t *testing.T) {
+	mc := NewMarkdownConverter(WithMarkdownParser(GoldmarkParser))
+	blocks, err := mc.ToBlocks("One.[^a] Two.[^a]\n\nThree.[^a]\n\n[^a]: A note.")
+	assert.NoError(t, err)
+	assert.Equal(t, "One.[^1] Two.[^1]\n\nThree.[^1]\n\n[^1]: A note.", ToMarkdown(blocks))
+}
+
+func TestToMarkdownLinkDestinations(t *testing.T) {
+	hrefs := []string{
+		"https://en.wikipedia.org/wiki/Go_(programming_language)",
+		"https://example.com/a b/(c)",
+		`https://example.com/it's "quoted"`,
+		`https://example.com/back\slash?q=a%20b&x=1`,
+		"/relative/path#frag",
+	}
+
+	for _, p := range markdownParsers {
+		mc := NewMarkdownConverter(WithMarkdownParser(p.parser))
+		for _, href := range hrefs {
+			t.Run(p.name+"/"+href, func(t *testing.T) {
+				b := New("normal", Text("link", "mark1"))
+				b.Content.(*BlockContent).MarkDefs = []MarkDef{
+					{Type: "link", Key: "mark1", Data: &LinkData{Href: href}},
+				}
+
+				again, err := mc.ToBlocks(ToMarkdown([]Block{b}))
+				assert.NoError(t, err)
+				assert.Equal(t, []MarkDef{
+					{Type: "link", Key: "mark1", Data: &LinkData{Href: href}},
+				}, again[0].Content.(*BlockContent).MarkDefs)
+			})
+		}
+	}
+}
+
+func TestToMarkdownEscapesText(t *testing.T) {
+	cases := []struct {
+		name  string
+		spans []string
+	}{
+		{name: "thematic break", spans: []string{"---"}},
+		{name: "equals signs", spans: []string{"==="}},
+		{name: "setext underlines", spans: []string{"Text\n---\nMore\n===\nAnd\n-"}},
+		{name: "block syntax after a line break", spans: []string{"Text\n# not a heading\n> not a quote\n- not a list\n1. not a list"}},
+		{name: "block syntax in a later span", spans: []string{"Text\n", "---"}},
+		{name: "paragraph break", spans: []string{"One\n\nTwo"}},
+		{name: "character references", spans: []string{"&copy; &#61; &#x3D; and AT&T"}},
+	}
+
+	for _, p := range markdownParsers {
+		mc := NewMarkdownConverter(WithMarkdownParser(p.parser))
+		for _, c := range cases {
+			t.Run(p.name+"/"+c.name, func(t *testing.T) {
+				var opts []BlockOption
+				for _, span := range c.spans {
+					opts = append(opts, Text(span))
+				}
+				b := New("normal", opts...)
+
+				again, err := mc.ToBlocks(ToMarkdown([]Block{b}))
+				assert.NoError(t, err)
+				if assert.Len(t, again, 1) {
+					assert.Equal(t, "normal", again[0].Content.(*BlockContent).Style)
+					assert.Equal(t, ToPlainText([]Block{b}), ToPlainText(again))
+				}
+			})
+		}
+	}
+}
+
+func TestToMarkdownImageCaptions(t *testing.T) {
+	captions := []string{
+		`A "quoted" caption`,
+		`Go\u00e9 \x and \* with a trailing \`,
+		"(Parenthesized) 'caption'",
+		"&copy; &#61; and AT&T",
+	}
+
+	images := ImageResolverFunc(func(ctx context.Context, src string) (Reference, error) {
+		return Reference(src), nil
+	})
+	for _, p := range markdownParsers {
+		mc := NewMarkdownConverter(WithMarkdownParser(p.parser), WithImageResolver(images))
+		for _, caption := range captions {
+			t.Run(p.name+"/"+caption, func(t *testing.T) {
+				b := Block{Type: "mainImage", Content: &ImageContent{
+					Asset:   "https://example.com/a.png",
+					Alt:     "Alt",
+					Caption: caption,
+				}}
+
+				again, err := mc.ToBlocks(ToMarkdown([]Block{b}))
+				assert.NoError(t, err)
+				if assert.Len(t, again, 1) {
+					assert.Equal(t, caption, again[0].Content.(*ImageContent).Caption)
+				}
+			})
+		}
+	}
+}
+
+func TestToMarkdownDecoratorSyntax(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{
+			name:   "all decorators",
+			input:  "==marked== ++under++ x^2^ H~2~O",
+			output: "==marked== ++under++ x^2^ H~2~O",
+		},
+		{
+			name:   "nested with other marks",
+			input:  "==a **bold** [link](https://example.com)== and **b ++c++**",
+			output: "==a **bold** [link](https://example.com)== and **b ++c++**",
+		},
+		{
+			name:   "link around decorators",
+			input:  "[==a== b](https://example.com)",
+			output: "[==a== b](https://example.com)",
+		},
+	}
+
+	mc := NewMarkdownConverter(WithDecoratorSyntax(DefaultDecoratorSyntax))
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blocks, err := mc.ToBlocks(c.input)
+			assert.NoError(t, err)
+
+			out := ToMarkdown(blocks, WithMarkdownDecoratorSyntax(DefaultDecoratorSyntax))
+			assert.Equal(t, c.output, out)
+
+			again, err := mc.ToBlocks(out)
+			assert.NoError(t, err)
+			assert.Equal(t, blocks, again)
+		})
+	}
+
+	// without the option, the decorators are left out
+	blocks, err := mc.ToBlocks("==marked== text")
+	assert.NoError(t, err)
+	assert.Equal(t, "marked text", ToMarkdown(blocks))
+
+	// only configured decorators are written
+	assert.Equal(t, "==marked== text", ToMarkdown(blocks, WithMarkdownDecoratorSyntax(DecoratorSyntax{Highlight: "highlight"})))
+	assert.Equal(t, "marked text", ToMarkdown(blocks, WithMarkdownDecoratorSyntax(DecoratorSyntax{Underline: "underline"})))
+}
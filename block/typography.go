@@ -0,0 +1,239 @@
+package block
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// QuoteStyle sets the characters that straight quotes are turned into.
+type QuoteStyle struct {
+	OpenDouble  string
+	CloseDouble string
+	OpenSingle  string
+	CloseSingle string
+}
+
+// Quote styles for some common languages. French quotes include a no-break space inside them.
+var (
+	EnglishQuotes  = QuoteStyle{"“", "”", "‘", "’"}
+	GermanQuotes   = QuoteStyle{"„", "“", "‚", "‘"}
+	FrenchQuotes   = QuoteStyle{"«\u00a0", "\u00a0»", "‹\u00a0", "\u00a0›"}
+	SpanishQuotes  = QuoteStyle{"«", "»", "“", "”"}
+	RussianQuotes  = QuoteStyle{"«", "»", "„", "“"}
+	SwedishQuotes  = QuoteStyle{"”", "”", "’", "’"}
+	JapaneseQuotes = QuoteStyle{"「", "」", "『", "』"}
+)
+
+var localeQuotes = map[string]QuoteStyle{
+	"en": EnglishQuotes,
+	"de": GermanQuotes,
+	"fr": FrenchQuotes,
+	"es": SpanishQuotes,
+	"it": SpanishQuotes,
+	"pt": SpanishQuotes,
+	"ru": RussianQuotes,
+	"uk": RussianQuotes,
+	"sv": SwedishQuotes,
+	"fi": SwedishQuotes,
+	"ja": JapaneseQuotes,
+}
+
+// QuotesForLocale returns the quote style for a locale like "en-US" or "de_DE". Only the language
+// is considered, and unknown languages use English quotes.
+func QuotesForLocale(locale string) QuoteStyle {
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, "-_"); i != -1 {
+		lang = lang[:i]
+	}
+	if qs, ok := localeQuotes[lang]; ok {
+		return qs
+	}
+	return EnglishQuotes
+}
+
+// Typography sets which SmartyPants-style replacements MarkdownConverter makes in text. Code and
+// URLs are never changed.
+type Typography struct {
+	// Quotes is the style for curly quotes. The zero value leaves quotes straight.
+	Quotes QuoteStyle
+	// Dashes turns -- into an en dash and --- into an em dash.
+	Dashes bool
+	// Ellipses turns ... into an ellipsis.
+	Ellipses bool
+	// Fractions turns 1/2, 1/4, 3/4, 1/3 and 2/3 into fraction characters.
+	Fractions bool
+}
+
+// DefaultTypography makes all of the replacements, using English quotes.
+var DefaultTypography = Typography{
+	Quotes:    EnglishQuotes,
+	Dashes:    true,
+	Ellipses:  true,
+	Fractions: true,
+}
+
+// TypographyForLocale is DefaultTypography with the quote style for a locale.
+func TypographyForLocale(locale string) Typography {
+	t := DefaultTypography
+	t.Quotes = QuotesForLocale(locale)
+	return t
+}
+
+// WithTypography turns on typographic replacements for text in Markdown.
+func WithTypography(t Typography) MarkdownOption {
+	return markdownOptionFn(func(mc *MarkdownConverter) {
+		mc.typography = &t
+	})
+}
+
+var (
+	urlPattern      = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.)\S+`)
+	fractionPattern = regexp.MustCompile(`\d+(?:/\d+)+`)
+	yearPattern     = regexp.MustCompile(`^\d\d(?:s|\b)`)
+)
+
+var fractions = map[string]string{
+	"1/2": "½",
+	"1/4": "¼",
+	"3/4": "¾",
+	"1/3": "⅓",
+	"2/3": "⅔",
+}
+
+// typographer tracks the text before the current node, since quotes are often split from the text
+// they surround by formatting.
+type typographer struct {
+	t *Typography
+
+	prev       rune
+	singleOpen bool
+}
+
+// apply makes the replacements in the text nodes under root. Code is left alone since it's in its
+// own nodes, as is the text of links to URLs.
+func (t *Typography) apply(root *blackfriday.Node) {
+	tp := &typographer{t: t}
+	root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		switch node.Type {
+		case blackfriday.Text:
+			if node.Parent != nil && node.Parent.Type == blackfriday.Link && isURLText(node) {
+				tp.skip(string(node.Literal))
+			} else {
+				node.Literal = []byte(tp.convert(string(node.Literal)))
+			}
+		case blackfriday.Code, blackfriday.HTMLSpan:
+			tp.skip(string(node.Literal))
+		case blackfriday.Hardbreak, blackfriday.Softbreak:
+			tp.prev = '\n'
+		case blackfriday.Emph, blackfriday.Strong, blackfriday.Del, blackfriday.Link, blackfriday.Image:
+			break
+		default:
+			// quotes don't carry over between blocks
+			tp.prev = 0
+			tp.singleOpen = false
+		}
+		return blackfriday.GoToNext
+	})
+}
+
+func isURLText(node *blackfriday.Node) bool {
+	text := string(node.Literal)
+	dest := string(node.Parent.LinkData.Destination)
+	return text == dest || text == strings.TrimPrefix(dest, "mailto:")
+}
+
+func (tp *typographer) skip(s string) {
+	if r, _ := utf8.DecodeLastRuneInString(s); r != utf8.RuneError {
+		tp.prev = r
+	}
+}
+
+func (tp *typographer) convert(s string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range urlPattern.FindAllStringIndex(s, -1) {
+		sb.WriteString(tp.convertText(s[last:loc[0]]))
+		sb.WriteString(s[loc[0]:loc[1]])
+		tp.skip(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(tp.convertText(s[last:]))
+	return sb.String()
+}
+
+func (tp *typographer) convertText(s string) string {
+	if s == "" {
+		return s
+	}
+
+	if tp.t.Dashes {
+		s = strings.ReplaceAll(s, "---", "—")
+		s = strings.ReplaceAll(s, "--", "–")
+	}
+	if tp.t.Ellipses {
+		s = strings.ReplaceAll(s, "...", "…")
+		s = strings.ReplaceAll(s, ". . .", "…")
+	}
+	if tp.t.Fractions {
+		s = fractionPattern.ReplaceAllStringFunc(s, func(m string) string {
+			// dates like 1/2/2020 match as a whole, so they're left alone
+			if f, ok := fractions[m]; ok {
+				return f
+			}
+			return m
+		})
+	}
+	if tp.t.Quotes == (QuoteStyle{}) {
+		tp.skip(s)
+		return s
+	}
+
+	var sb strings.Builder
+	for i, r := range s {
+		next, _ := utf8.DecodeRuneInString(s[i+utf8.RuneLen(r):])
+		switch r {
+		case '"':
+			if tp.opening(next) {
+				sb.WriteString(tp.t.Quotes.OpenDouble)
+			} else {
+				sb.WriteString(tp.t.Quotes.CloseDouble)
+			}
+		case '\'':
+			switch {
+			case unicode.IsLetter(tp.prev) || unicode.IsDigit(tp.prev):
+				if tp.singleOpen && !unicode.IsLetter(next) {
+					sb.WriteString(tp.t.Quotes.CloseSingle)
+					tp.singleOpen = false
+				} else {
+					// an apostrophe
+					sb.WriteRune('’')
+				}
+			case yearPattern.MatchString(s[i+1:]):
+				// an abbreviated year, like '90s
+				sb.WriteRune('’')
+			case tp.opening(next):
+				sb.WriteString(tp.t.Quotes.OpenSingle)
+				tp.singleOpen = true
+			default:
+				sb.WriteString(tp.t.Quotes.CloseSingle)
+				tp.singleOpen = false
+			}
+		default:
+			sb.WriteRune(r)
+		}
+		tp.prev = r
+	}
+	return sb.String()
+}
+
+// opening decides whether a quote starts a quotation based on the characters around it.
+func (tp *typographer) opening(next rune) bool {
+	if next != utf8.RuneError && unicode.IsSpace(next) {
+		return false
+	}
+	return tp.prev == 0 || unicode.IsSpace(tp.prev) || strings.ContainsRune("([{<-–—/\"'", tp.prev)
+}
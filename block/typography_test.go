@@ -0,0 +1,100 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownTypography(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "quotes",
+			input:    `"Hello," she said. 'It's fine.'`,
+			expected: "“Hello,” she said. ‘It’s fine.’",
+		},
+		{
+			name:     "quotes around formatting",
+			input:    `"*emphasis*" and '**strong**' in (“parens”)`,
+			expected: "“emphasis” and ‘strong’ in (“parens”)",
+		},
+		{
+			name:     "apostrophes",
+			input:    "Rock 'n' roll from the '90s isn't dead",
+			expected: "Rock ‘n’ roll from the ’90s isn’t dead",
+		},
+		{
+			name:     "dashes and ellipses",
+			input:    "Wait--what... no---never. . .",
+			expected: "Wait–what… no—never…",
+		},
+		{
+			name:     "fractions",
+			input:    "Add 1/2 cup, 3/4 tsp and 1/3 of the rest on 1/2/2020, not 5/8",
+			expected: "Add ½ cup, ¾ tsp and ⅓ of the rest on 1/2/2020, not 5/8",
+		},
+		{
+			name:     "code is left alone",
+			input:    "Run `echo \"a--b\"...` \"now\"\n\n```\nprint('x--y')\n```",
+			expected: "Run echo \"a--b\"... “now”\n\nprint('x--y')",
+		},
+		{
+			name:     "urls are left alone",
+			input:    "See https://example.com/a--b...c and [\"this\"](https://example.com/'x') or <https://example.com/1/2>",
+			expected: "See https://example.com/a--b...c and “this” or https://example.com/1/2",
+		},
+		{
+			name:     "quotes don't carry over between paragraphs",
+			input:    "It's\n\n'Quoted'",
+			expected: "It’s\n\n‘Quoted’",
+		},
+	}
+
+	mc := NewMarkdownConverter(WithTypography(DefaultTypography))
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, err := mc.ToBlocks(c.input)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, ToPlainText(out, WithCodeText()))
+		})
+	}
+}
+
+func TestMarkdownTypographyLocale(t *testing.T) {
+	cases := []struct {
+		locale   string
+		expected string
+	}{
+		{"en-US", "“Hallo” ‘Welt’"},
+		{"de_DE", "„Hallo“ ‚Welt‘"},
+		{"fr", "«\u00a0Hallo\u00a0» ‹\u00a0Welt\u00a0›"},
+		{"ja", "「Hallo」 『Welt』"},
+		{"xx", "“Hallo” ‘Welt’"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.locale, func(t *testing.T) {
+			mc := NewMarkdownConverter(WithTypography(TypographyForLocale(c.locale)))
+			out, err := mc.ToBlocks(`"Hallo" 'Welt'`)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, ToPlainText(out))
+		})
+	}
+}
+
+func TestMarkdownTypographyOptions(t *testing.T) {
+	mc := NewMarkdownConverter(WithTypography(Typography{Dashes: true}))
+
+	out, err := mc.ToBlocks(`"a--b" ... 1/2`)
+	assert.NoError(t, err)
+	assert.Equal(t, `"a–b" ... 1/2`, ToPlainText(out))
+
+	out, err = NewMarkdownConverter().ToBlocks(`"a--b"`)
+	assert.NoError(t, err)
+	assert.Equal(t, `"a--b"`, ToPlainText(out))
+}
@@ -0,0 +1,171 @@
+package block
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError describes a structural problem with a block.
+type ValidationError struct {
+	// Index is the position of the block in the body.
+	Index int
+	// Key is the block's _key, if it has one.
+	Key string
+	// Child is the position of the span or inline object in the block that has the problem, or -1
+	// if the problem is with the block itself.
+	Child   int
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "block %d", e.Index)
+	if e.Key != "" {
+		fmt.Fprintf(&s, " (%q)", e.Key)
+	}
+	if e.Child >= 0 {
+		fmt.Fprintf(&s, " child %d", e.Child)
+	}
+	s.WriteString(": ")
+	s.WriteString(e.Message)
+	return s.String()
+}
+
+// ValidationErrors collects every problem found by Validate.
+type ValidationErrors []*ValidationError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return fmt.Sprintf("invalid blocks: %s", strings.Join(msgs, "; "))
+}
+
+type ValidateOption interface {
+	Apply(v *blockValidator)
+}
+
+type validateOptionFn func(v *blockValidator)
+
+func (fn validateOptionFn) Apply(v *blockValidator) {
+	fn(v)
+}
+
+// WithDecorators sets the marks that are allowed on spans without a mark definition. By default,
+// these are the decorators that the HTML renderer knows about.
+func WithDecorators(names ...string) ValidateOption {
+	return validateOptionFn(func(v *blockValidator) {
+		v.decorators = make(map[string]bool)
+		for _, name := range names {
+			v.decorators[name] = true
+		}
+	})
+}
+
+type blockValidator struct {
+	decorators map[string]bool
+	errs       ValidationErrors
+}
+
+// Validate checks the structure of a body of blocks: that every block and child has a type, keys
+// are unique, text blocks have a style and children, list items have a level, and every mark on a
+// span is either a decorator or the key of one of the block's mark definitions. Any problems are
+// returned as ValidationErrors.
+func Validate(blocks []Block, opts ...ValidateOption) error {
+	v := &blockValidator{
+		decorators: map[string]bool{
+			"strong":         true,
+			"em":             true,
+			"code":           true,
+			"underline":      true,
+			"strike-through": true,
+			"highlight":      true,
+			"sup":            true,
+			"sub":            true,
+		},
+	}
+	for _, o := range opts {
+		o.Apply(v)
+	}
+
+	keys := make(map[string]int)
+	for i, b := range blocks {
+		if b.Type == "" {
+			v.errorf(i, b, -1, "missing _type")
+		}
+		if b.Key != "" {
+			if j, ok := keys[b.Key]; ok {
+				v.errorf(i, b, -1, "duplicate _key, also used by block %d", j)
+			} else {
+				keys[b.Key] = i
+			}
+		}
+
+		if bc, ok := b.Content.(*BlockContent); ok {
+			v.textBlock(i, b, bc)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+func (v *blockValidator) errorf(i int, b Block, child int, format string, args ...interface{}) {
+	v.errs = append(v.errs, &ValidationError{
+		Index:   i,
+		Key:     b.Key,
+		Child:   child,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *blockValidator) textBlock(i int, b Block, bc *BlockContent) {
+	if bc.Style == "" {
+		v.errorf(i, b, -1, "missing style")
+	}
+	if bc.ListItem != "" && bc.Level < 1 {
+		v.errorf(i, b, -1, "list item %q has no level", bc.ListItem)
+	}
+	if len(bc.Children) == 0 {
+		v.errorf(i, b, -1, "has no children")
+	}
+
+	markDefs := make(map[string]bool)
+	for _, md := range bc.MarkDefs {
+		switch {
+		case md.Key == "":
+			v.errorf(i, b, -1, "mark definition of type %q has no _key", md.Type)
+		case markDefs[md.Key]:
+			v.errorf(i, b, -1, "duplicate mark definition %q", md.Key)
+		case v.decorators[md.Key]:
+			v.errorf(i, b, -1, "mark definition %q has the same key as a decorator", md.Key)
+		}
+		markDefs[md.Key] = true
+	}
+
+	childKeys := make(map[string]bool)
+	for j, child := range bc.Children {
+		if child.Type == "" {
+			v.errorf(i, b, j, "missing _type")
+		}
+		if child.Key != "" {
+			if childKeys[child.Key] {
+				v.errorf(i, b, j, "duplicate _key %q", child.Key)
+			}
+			childKeys[child.Key] = true
+		}
+
+		sc, ok := child.Content.(*SpanContent)
+		if !ok {
+			continue
+		}
+		for _, mark := range sc.Marks {
+			if !markDefs[mark] && !v.decorators[mark] {
+				v.errorf(i, b, j, "mark %q is not a decorator or mark definition", mark)
+			}
+		}
+	}
+}
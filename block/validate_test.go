@@ -0,0 +1,69 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	blocks := []Block{
+		{
+			Type: "block",
+			Key:  "abc",
+			Content: &BlockContent{
+				Style: "normal",
+				Children: []Block{
+					{Type: "span", Key: "s1", Content: &SpanContent{Text: "A ", Marks: []string{"strong"}}},
+					{Type: "span", Key: "s1", Content: &SpanContent{Text: "link", Marks: []string{"l1", "missing"}}},
+				},
+				MarkDefs: []MarkDef{
+					{Type: "link", Key: "l1", Data: &LinkData{Href: "https://example.com"}},
+				},
+			},
+		},
+		{
+			Type: "block",
+			Key:  "def",
+			Content: &BlockContent{
+				ListItem: "bullet",
+				Children: []Block{},
+				MarkDefs: []MarkDef{},
+			},
+		},
+		{
+			Type:    TypeCode,
+			Key:     "abc",
+			Content: &CodeContent{Code: "x := 1"},
+		},
+		{
+			Content: &CodeContent{Code: "y := 2"},
+		},
+	}
+
+	err := Validate(blocks)
+	assert.Equal(t, ValidationErrors{
+		{Index: 0, Key: "abc", Child: 1, Message: `duplicate _key "s1"`},
+		{Index: 0, Key: "abc", Child: 1, Message: `mark "missing" is not a decorator or mark definition`},
+		{Index: 1, Key: "def", Child: -1, Message: "missing style"},
+		{Index: 1, Key: "def", Child: -1, Message: `list item "bullet" has no level`},
+		{Index: 1, Key: "def", Child: -1, Message: "has no children"},
+		{Index: 2, Key: "abc", Child: -1, Message: "duplicate _key, also used by block 0"},
+		{Index: 3, Child: -1, Message: "missing _type"},
+	}, err)
+	assert.EqualError(t, err.(ValidationErrors)[1],
+		`block 0 ("abc") child 1: mark "missing" is not a decorator or mark definition`)
+
+	err = Validate(blocks[:1], WithDecorators("missing"))
+	assert.Equal(t, ValidationErrors{
+		{Index: 0, Key: "abc", Child: 0, Message: `mark "strong" is not a decorator or mark definition`},
+		{Index: 0, Key: "abc", Child: 1, Message: `duplicate _key "s1"`},
+	}, err)
+}
+
+func TestValidateMarkdownOutput(t *testing.T) {
+	mc := NewMarkdownConverter()
+	blocks, err := mc.ToBlocks("# Title\n\nSome **bold** and [linked](https://example.com) `code`.\n\n- one\n- two\n")
+	assert.NoError(t, err)
+	assert.NoError(t, Validate(blocks))
+}
@@ -0,0 +1,258 @@
+package block
+
+import (
+	"fmt"
+)
+
+// NodeKind is the kind of node a Cursor is positioned at.
+type NodeKind int
+
+const (
+	// BlockNode is a block in a body, either a text block or a custom block. Blocks in the text of
+	// a footnote are block nodes too, with the footnote's mark definition as their parent.
+	BlockNode NodeKind = iota
+	// ChildNode is a span or inline object in a text block.
+	ChildNode
+	// MarkDefNode is a mark definition of a text block.
+	MarkDefNode
+)
+
+func (k NodeKind) String() string {
+	switch k {
+	case BlockNode:
+		return "block"
+	case ChildNode:
+		return "child"
+	case MarkDefNode:
+		return "markDef"
+	default:
+		return fmt.Sprintf("NodeKind(%d)", int(k))
+	}
+}
+
+// WalkStatus tells Walk how to continue after visiting a node.
+type WalkStatus int
+
+const (
+	// GoToNext walks the children of the node, if it has any, and then the nodes after it.
+	GoToNext WalkStatus = iota
+	// SkipChildren moves on to the node after this one without walking its children. The node
+	// will not be visited again when exiting it.
+	SkipChildren
+	// Terminate stops walking. Nodes that haven't been visited yet are kept as they are.
+	Terminate
+)
+
+// WalkFunc is called for each node visited by Walk. Nodes that have children, like text blocks
+// and footnote mark definitions, are visited twice: once when entering them, and once after
+// their children when exiting them.
+type WalkFunc func(c *Cursor, entering bool) WalkStatus
+
+// Cursor is a position in the blocks being walked. Besides describing the current node and its
+// parents, it lets a WalkFunc change the current node.
+type Cursor struct {
+	kind    NodeKind
+	parent  *Cursor
+	index   int
+	block   *Block
+	markDef *MarkDef
+
+	deleted       bool
+	before, after []Block
+}
+
+// Kind returns the kind of node the cursor is at.
+func (c *Cursor) Kind() NodeKind {
+	return c.kind
+}
+
+// Index returns the position of the node in its parent's list, before any changes by the walk.
+func (c *Cursor) Index() int {
+	return c.index
+}
+
+// Parent returns the cursor for the node containing this one, or nil for a top-level block. The
+// parent of a child or mark definition is its text block.
+func (c *Cursor) Parent() *Cursor {
+	return c.parent
+}
+
+// Block returns the current block, span or inline object, or nil at a mark definition.
+func (c *Cursor) Block() *Block {
+	return c.block
+}
+
+// MarkDef returns the current mark definition, or nil if the cursor is at a block or child.
+func (c *Cursor) MarkDef() *MarkDef {
+	return c.markDef
+}
+
+// TextBlock returns the content of the text block the cursor is in: the current block itself if
+// it's a text block, or the parent of a child or mark definition.
+func (c *Cursor) TextBlock() *BlockContent {
+	for cur := c; cur != nil; cur = cur.parent {
+		if cur.block != nil {
+			if bc, ok := cur.block.Content.(*BlockContent); ok {
+				return bc
+			}
+		}
+		if cur.kind == BlockNode {
+			break
+		}
+	}
+	return nil
+}
+
+// Replace replaces the current block or child. When entering a text block, the children of the
+// replacement are walked instead of the original's.
+func (c *Cursor) Replace(b Block) {
+	if c.block == nil {
+		panic("block: Replace called on a mark definition")
+	}
+	*c.block = b
+}
+
+// ReplaceMarkDef replaces the current mark definition.
+func (c *Cursor) ReplaceMarkDef(md MarkDef) {
+	if c.markDef == nil {
+		panic(fmt.Sprintf("block: ReplaceMarkDef called on a %s", c.kind))
+	}
+	*c.markDef = md
+}
+
+// Delete removes the current node. Its children aren't walked.
+func (c *Cursor) Delete() {
+	c.deleted = true
+}
+
+// InsertBefore adds a block or child before the current one. Inserted nodes are not walked.
+func (c *Cursor) InsertBefore(b Block) {
+	if c.block == nil {
+		panic("block: InsertBefore called on a mark definition")
+	}
+	c.before = append(c.before, b)
+}
+
+// InsertAfter adds a block or child after the current one, following any inserted before by
+// earlier calls. Inserted nodes are not walked.
+func (c *Cursor) InsertAfter(b Block) {
+	if c.block == nil {
+		panic("block: InsertAfter called on a mark definition")
+	}
+	c.after = append(c.after, b)
+}
+
+// Walk visits every block in a body in order, along with the children and mark definitions of
+// text blocks, and returns the blocks with any changes made through the Cursor. The blocks passed
+// in are not modified, as long as fn only makes changes through the Cursor.
+func Walk(blocks []Block, fn WalkFunc) []Block {
+	w := &blockWalker{fn: fn}
+	return w.blocks(nil, BlockNode, blocks)
+}
+
+type blockWalker struct {
+	fn   WalkFunc
+	done bool
+}
+
+func (w *blockWalker) blocks(parent *Cursor, kind NodeKind, blocks []Block) []Block {
+	if blocks == nil {
+		return nil
+	}
+
+	out := make([]Block, 0, len(blocks))
+	for i := range blocks {
+		if w.done {
+			out = append(out, blocks[i:]...)
+			break
+		}
+
+		b := blocks[i]
+		c := &Cursor{kind: kind, parent: parent, index: i, block: &b}
+		status := w.visit(c, true)
+
+		if bc, ok := b.Content.(*BlockContent); ok && kind == BlockNode && status == GoToNext && !c.deleted {
+			b.Content = w.text(c, bc)
+			if !w.done {
+				w.visit(c, false)
+			}
+		}
+
+		out = append(out, c.before...)
+		if !c.deleted {
+			out = append(out, b)
+		}
+		out = append(out, c.after...)
+	}
+	return out
+}
+
+func (w *blockWalker) text(parent *Cursor, bc *BlockContent) *BlockContent {
+	nbc := *bc
+	nbc.Children = w.blocks(parent, ChildNode, bc.Children)
+
+	if bc.MarkDefs == nil {
+		return &nbc
+	}
+	nbc.MarkDefs = make([]MarkDef, 0, len(bc.MarkDefs))
+	for i := range bc.MarkDefs {
+		if w.done {
+			nbc.MarkDefs = append(nbc.MarkDefs, bc.MarkDefs[i:]...)
+			break
+		}
+
+		md := bc.MarkDefs[i]
+		c := &Cursor{kind: MarkDefNode, parent: parent, index: i, markDef: &md}
+		status := w.visit(c, true)
+
+		if fd, ok := md.Data.(*FootnoteData); ok && status == GoToNext && !c.deleted {
+			nfd := *fd
+			nfd.Text = w.blocks(c, BlockNode, fd.Text)
+			md.Data = &nfd
+			if !w.done {
+				w.visit(c, false)
+			}
+		}
+
+		if !c.deleted {
+			nbc.MarkDefs = append(nbc.MarkDefs, md)
+		}
+	}
+	return &nbc
+}
+
+func (w *blockWalker) visit(c *Cursor, entering bool) WalkStatus {
+	status := w.fn(c, entering)
+	if status == Terminate {
+		w.done = true
+	}
+	return status
+}
+
+// RewriteLinks replaces the href of every link in the blocks, including those in footnotes, with
+// the result of calling fn with the original href.
+func RewriteLinks(blocks []Block, fn func(href string) string) []Block {
+	return Walk(blocks, func(c *Cursor, entering bool) WalkStatus {
+		md := c.MarkDef()
+		if !entering || md == nil || md.Type != "link" {
+			return GoToNext
+		}
+
+		switch data := md.Data.(type) {
+		case map[string]interface{}:
+			nd := make(map[string]interface{}, len(data))
+			for k, v := range data {
+				nd[k] = v
+			}
+			href, _ := data["href"].(string)
+			nd["href"] = fn(href)
+			c.ReplaceMarkDef(MarkDef{Type: md.Type, Key: md.Key, Data: nd})
+		default:
+			var link LinkData
+			decodeContent(md.Data, &link)
+			link.Href = fn(link.Href)
+			c.ReplaceMarkDef(MarkDef{Type: md.Type, Key: md.Key, Data: &link})
+		}
+		return GoToNext
+	})
+}
@@ -0,0 +1,129 @@
+package block
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkVisitsNodes(t *testing.T) {
+	mc := NewMarkdownConverter()
+	blocks, err := mc.ToBlocks("Some [link](https://example.com).[^1]\n\n    code\n\n[^1]: A note.")
+	assert.NoError(t, err)
+
+	var visits []string
+	Walk(blocks, func(c *Cursor, entering bool) WalkStatus {
+		var depth int
+		for p := c.Parent(); p != nil; p = p.Parent() {
+			depth++
+		}
+
+		var desc string
+		switch c.Kind() {
+		case MarkDefNode:
+			desc = c.MarkDef().Type
+		case ChildNode:
+			desc = c.Block().Content.(*SpanContent).Text
+		default:
+			desc = c.Block().Type
+		}
+		if !entering {
+			desc = "/" + desc
+		}
+		visits = append(visits, strings.Repeat("  ", depth)+c.Kind().String()+" "+desc)
+		return GoToNext
+	})
+
+	assert.Equal(t, []string{
+		"block block",
+		"  child Some ",
+		"  child link",
+		"  child .",
+		"  child 1",
+		"  markDef link",
+		"  markDef footnote",
+		"    block block",
+		"      child A note.",
+		"    block /block",
+		"  markDef /footnote",
+		"block /block",
+		"block code",
+	}, visits)
+}
+
+func TestWalkChanges(t *testing.T) {
+	blocks := []Block{
+		New("normal", Text("Keep "), Text("drop", "strong"), Text(" me")),
+		{Type: TypeBreak, Content: &BreakContent{}},
+		New("h1", Text("Title")),
+	}
+
+	out := Walk(blocks, func(c *Cursor, entering bool) WalkStatus {
+		b := c.Block()
+		switch {
+		case c.Kind() == BlockNode && b.Type == TypeBreak:
+			c.Delete()
+		case c.Kind() == BlockNode && entering && c.TextBlock().Style == "h1":
+			c.InsertBefore(New("normal", Text("Before")))
+			c.InsertAfter(New("normal", Text("After")))
+			return SkipChildren
+		case c.Kind() == ChildNode:
+			sc := b.Content.(*SpanContent)
+			if len(sc.Marks) > 0 {
+				c.Delete()
+			} else {
+				c.Replace(Block{Type: "span", Content: &SpanContent{Text: strings.ToUpper(sc.Text), Marks: sc.Marks}})
+			}
+		}
+		return GoToNext
+	})
+
+	assert.Equal(t, []Block{
+		New("normal", Text("KEEP "), Text(" ME")),
+		New("normal", Text("Before")),
+		New("h1", Text("Title")),
+		New("normal", Text("After")),
+	}, out)
+
+	// the original blocks are left alone
+	assert.Len(t, blocks, 3)
+	assert.Equal(t, "Keep ", blocks[0].Content.(*BlockContent).Children[0].Content.(*SpanContent).Text)
+}
+
+func TestWalkTerminate(t *testing.T) {
+	blocks := []Block{
+		New("normal", Text("one")),
+		New("normal", Text("two")),
+		New("normal", Text("three")),
+	}
+
+	var visited int
+	out := Walk(blocks, func(c *Cursor, entering bool) WalkStatus {
+		if c.Kind() != BlockNode || !entering {
+			return GoToNext
+		}
+		visited++
+		if c.Index() == 1 {
+			c.Delete()
+			return Terminate
+		}
+		return GoToNext
+	})
+
+	assert.Equal(t, 2, visited)
+	assert.Equal(t, []Block{blocks[0], blocks[2]}, out)
+}
+
+func TestRewriteLinks(t *testing.T) {
+	mc := NewMarkdownConverter()
+	blocks, err := mc.ToBlocks("A [link](/about).[^1]\n\n[^1]: See [this](/other).")
+	assert.NoError(t, err)
+
+	out := RewriteLinks(blocks, func(href string) string {
+		return "https://example.com" + href
+	})
+
+	assert.Equal(t, "A [link](https://example.com/about).[^1]\n\n[^1]: See [this](https://example.com/other).", ToMarkdown(out))
+	assert.Equal(t, "A [link](/about).[^1]\n\n[^1]: See [this](/other).", ToMarkdown(blocks))
+}
@@ -13,6 +13,9 @@ type Client struct {
 	Token     string
 
 	HTTPClient *http.Client
+	Keys       KeyGenerator
+
+	validators map[string][]Validator
 }
 
 type Option interface {
@@ -33,10 +36,18 @@ func (t WithToken) Apply(c *Client) error {
 	return nil
 }
 
+type WithKeySeed int64
+
+func (s WithKeySeed) Apply(c *Client) error {
+	c.Keys = NewKeyGenerator(int64(s))
+	return nil
+}
+
 func New(projectID string, opts ...Option) (*Client, error) {
 	c := &Client{
 		ProjectID:  projectID,
 		HTTPClient: &http.Client{},
+		Keys:       newRandomKeyGenerator(),
 	}
 
 	for _, o := range opts {
@@ -62,3 +73,18 @@ func (c *Client) newRequest(ctx context.Context, method string, path string, bod
 
 	return r, nil
 }
+
+// checkResponse returns an error for a response with an error status.
+func checkResponse(res *http.Response) error {
+	if res.StatusCode >= 500 {
+		// TODO parse messages out of error response
+		return fmt.Errorf("unexpected server error %d", res.StatusCode)
+	}
+
+	if res.StatusCode >= 400 {
+		// TODO parse messages out of error response
+		return fmt.Errorf("unexpected client error %d", res.StatusCode)
+	}
+
+	return nil
+}
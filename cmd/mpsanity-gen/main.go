@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/mjm/mpsanity/schema"
+)
+
+var (
+	schemaPath = flag.String("schema", "", "Path to a JSON file describing the Sanity schema types (default stdin)")
+	pkg        = flag.String("package", "sanitytypes", "Package name for the generated code")
+	outPath    = flag.String("out", "", "Path to write the generated Go code to (default stdout)")
+)
+
+func main() {
+	flag.Parse()
+
+	var data []byte
+	var err error
+	if *schemaPath == "" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(*schemaPath)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s, err := schema.ParseJSON(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := s.GenerateGo(*pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *outPath == "" {
+		if _, err := os.Stdout.Write(src); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := ioutil.WriteFile(*outPath, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
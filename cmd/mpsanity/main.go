@@ -24,6 +24,10 @@ var (
 	baseURL    = flag.String("base-url", "", "Base URL for the website posts are published to")
 	webhookURL = flag.String("webhook-url", "", "Netlify webhook URL to rebuild the site")
 	tokenURL   = flag.String("token-url", "", "IndieAuth token endpoint")
+	studioDir  = flag.String("studio-schema", "", "Write Sanity Studio schema files to this directory and exit")
+	typography = flag.String("typography", "", "Use curly quotes, dashes and ellipses in posts, with quotes for this locale")
+	commonMark = flag.Bool("commonmark", false, "Parse posts with a CommonMark-compliant Markdown parser")
+	decorators = flag.Bool("decorators", false, "Parse ==highlight==, ++underline++, ^superscript^ and ~subscript~ in posts")
 
 	port = flag.String("port", "9090", "Port to listen on for HTTP")
 )
@@ -35,6 +39,13 @@ func init() {
 func main() {
 	flag.Parse()
 
+	if *studioDir != "" {
+		if err := mpapi.DefaultSchema.WriteStudioFiles(*studioDir); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	exporter, err := stdout.NewExporter(stdout.Options{PrettyPrint: true})
 	if err != nil {
 		log.Fatal(err)
@@ -55,11 +66,44 @@ func main() {
 
 	sanity.HTTPClient.Transport = tracehttp.DefaultTransport
 
+	// the same rules apply to posts sent as Markdown and as HTML
+	rules := []block.MarkdownRuleFunc{
+		block.TweetMarkdownRule,
+		block.YouTubeMarkdownRule,
+		block.VimeoMarkdownRule,
+		block.InstagramMarkdownRule,
+		block.MastodonMarkdownRule,
+		block.GistMarkdownRule,
+		block.CodePenMarkdownRule,
+		block.SpotifyMarkdownRule,
+		block.BlueskyMarkdownRule,
+		block.MentionMarkdownRule,
+	}
+	imageResolver := &block.SanityImageResolver{Client: sanity, HTTPClient: sanity.HTTPClient}
+	linkResolver := &block.SanityLinkResolver{Client: sanity, BaseURL: *baseURL}
+
+	markdownOpts := []block.MarkdownOption{
+		block.WithMarkdownRules(rules...),
+		block.WithImageResolver(imageResolver),
+		block.WithLinkResolver(linkResolver),
+	}
+	if *commonMark {
+		markdownOpts = append(markdownOpts, block.WithMarkdownParser(block.GoldmarkParser))
+	}
+	if *decorators {
+		markdownOpts = append(markdownOpts, block.WithDecoratorSyntax(block.DefaultDecoratorSyntax))
+	}
+	if *typography != "" {
+		markdownOpts = append(markdownOpts, block.WithTypography(block.TypographyForLocale(*typography)))
+	}
+
 	http.Handle("/", mpapi.New(sanity,
 		mpapi.WithDocumentBuilder(&mpapi.DefaultDocumentBuilder{
-			MarkdownConverter: block.NewMarkdownConverter(block.WithMarkdownRules(
-				block.TweetMarkdownRule,
-				block.YouTubeMarkdownRule)),
+			MarkdownConverter: block.NewMarkdownConverter(markdownOpts...),
+			HTMLConverter: block.NewHTMLConverter(
+				block.WithHTMLMarkdownRules(rules...),
+				block.WithHTMLImageResolver(imageResolver),
+				block.WithHTMLLinkResolver(linkResolver)),
 		}),
 		mpapi.WithBaseURL(*baseURL),
 		mpapi.WithWebhookURL(*webhookURL),
@@ -19,6 +19,8 @@ var (
 	docID     = flag.String("doc", "", "Sanity document ID to fetch")
 	query     = flag.String("query", "", "Sanity query to run")
 	mutate    = flag.Bool("mutate", false, "Test mutations")
+	revision  = flag.String("rev", "", "Revision of the document to fetch")
+	history   = flag.Bool("history", false, "List the transactions for the document")
 )
 
 func main() {
@@ -29,7 +31,27 @@ func main() {
 		log.Fatal(err)
 	}
 
-	if *docID != "" {
+	if *docID != "" && *history {
+		txns, err := sanity.Transactions(context.Background(), *docID)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, txn := range txns {
+			fmt.Printf("%s %s %s (%d mutations)\n", txn.ID, txn.Timestamp.Format(time.RFC3339), txn.Author, len(txn.Mutations))
+		}
+	} else if *docID != "" && *revision != "" {
+		var doc struct {
+			Body        []block.Block `json:"body"`
+			PublishedAt time.Time     `json:"publishedAt"`
+			Slug        mpsanity.Slug `json:"slug"`
+		}
+		if err := sanity.DocAtRevision(context.Background(), *docID, *revision, &doc); err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("%+v\n", doc)
+	} else if *docID != "" {
 		var doc struct {
 			Body        []block.Block `json:"body"`
 			PublishedAt time.Time     `json:"publishedAt"`
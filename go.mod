@@ -3,12 +3,16 @@ module github.com/mjm/mpsanity
 go 1.14
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/gosimple/slug v1.9.0
 	github.com/mjm/courier-js v0.0.0-20200330054733-06c149e9fba5
 	github.com/russross/blackfriday/v2 v2.0.1
 	github.com/shurcooL/sanitized_anchor_name v1.0.0 // indirect
 	github.com/stretchr/testify v1.5.1
+	github.com/yuin/goldmark v1.2.1
 	go.opentelemetry.io/otel v0.4.2
+	golang.org/x/net v0.0.0-20200301022130-244492dfa37a
 	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
 	google.golang.org/grpc v1.27.1
+	gopkg.in/yaml.v2 v2.2.7
 )
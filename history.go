@@ -0,0 +1,220 @@
+package mpsanity
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/api/trace"
+)
+
+// ErrNotFound is returned when the history has no version of a document at the requested revision
+// or time.
+var ErrNotFound = errors.New("document not found")
+
+// Transaction is a single entry in a document's history.
+type Transaction struct {
+	ID          string            `json:"id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Author      string            `json:"author"`
+	DocumentIDs []string          `json:"documentIDs"`
+	Mutations   []json.RawMessage `json:"mutations"`
+}
+
+// DocAtRevision fetches a document as it was at the given revision (transaction ID).
+func (c *Client) DocAtRevision(ctx context.Context, id string, rev string, out interface{}) error {
+	ctx, span := tracer.Start(ctx, "sanity.DocAtRevision",
+		trace.WithAttributes(
+			projectIDKey(c.ProjectID),
+			datasetKey(c.Dataset),
+			docIDKey(id),
+			revisionKey(rev)))
+	defer span.End()
+
+	q := url.Values{
+		"revision": []string{rev},
+	}
+	if err := c.historyDoc(ctx, id, q, out); err != nil {
+		span.RecordError(ctx, err)
+		return err
+	}
+	return nil
+}
+
+// DocAtTime fetches a document as it was at the given point in time.
+func (c *Client) DocAtTime(ctx context.Context, id string, t time.Time, out interface{}) error {
+	ctx, span := tracer.Start(ctx, "sanity.DocAtTime",
+		trace.WithAttributes(
+			projectIDKey(c.ProjectID),
+			datasetKey(c.Dataset),
+			docIDKey(id),
+			historyTimeKey(t.Format(time.RFC3339))))
+	defer span.End()
+
+	q := url.Values{
+		"time": []string{t.UTC().Format(time.RFC3339)},
+	}
+	if err := c.historyDoc(ctx, id, q, out); err != nil {
+		span.RecordError(ctx, err)
+		return err
+	}
+	return nil
+}
+
+func (c *Client) historyDoc(ctx context.Context, id string, q url.Values, out interface{}) error {
+	r, err := c.newRequest(ctx, http.MethodGet, fmt.Sprintf("/data/history/%s/documents/%s?%s", c.Dataset, id, q.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.HTTPClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err := checkResponse(res); err != nil {
+		return err
+	}
+
+	var result docResult
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	if len(result.Docs) == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return json.Unmarshal(result.Docs[0], out)
+}
+
+type TransactionsOption interface {
+	Apply(q url.Values)
+}
+
+type transactionsOptionFn func(q url.Values)
+
+func (fn transactionsOptionFn) Apply(q url.Values) {
+	fn(q)
+}
+
+// FromTime limits the transactions to those made at or after t.
+func FromTime(t time.Time) TransactionsOption {
+	return transactionsOptionFn(func(q url.Values) {
+		q.Set("fromTime", t.UTC().Format(time.RFC3339))
+	})
+}
+
+// ToTime limits the transactions to those made at or before t.
+func ToTime(t time.Time) TransactionsOption {
+	return transactionsOptionFn(func(q url.Values) {
+		q.Set("toTime", t.UTC().Format(time.RFC3339))
+	})
+}
+
+// FromTransaction limits the transactions to those after the one with the given ID.
+func FromTransaction(id string) TransactionsOption {
+	return transactionsOptionFn(func(q url.Values) {
+		q.Set("fromTransaction", id)
+	})
+}
+
+// ToTransaction limits the transactions to those up to and including the one with the given ID.
+func ToTransaction(id string) TransactionsOption {
+	return transactionsOptionFn(func(q url.Values) {
+		q.Set("toTransaction", id)
+	})
+}
+
+// Reverse returns the newest transactions first.
+func Reverse() TransactionsOption {
+	return transactionsOptionFn(func(q url.Values) {
+		q.Set("reverse", "true")
+	})
+}
+
+// Limit returns at most n transactions.
+func Limit(n int) TransactionsOption {
+	return transactionsOptionFn(func(q url.Values) {
+		q.Set("limit", strconv.Itoa(n))
+	})
+}
+
+// ExcludeContent leaves out the mutations of each transaction, for when only the IDs, times and
+// authors are needed.
+func ExcludeContent() TransactionsOption {
+	return transactionsOptionFn(func(q url.Values) {
+		q.Set("excludeContent", "true")
+	})
+}
+
+// Transactions lists the transactions that have modified a document, including the mutations
+// that were applied in each unless ExcludeContent is used.
+func (c *Client) Transactions(ctx context.Context, id string, opts ...TransactionsOption) ([]Transaction, error) {
+	ctx, span := tracer.Start(ctx, "sanity.Transactions",
+		trace.WithAttributes(
+			projectIDKey(c.ProjectID),
+			datasetKey(c.Dataset),
+			docIDKey(id)))
+	defer span.End()
+
+	q := url.Values{
+		"excludeContent": []string{"false"},
+	}
+	for _, o := range opts {
+		o.Apply(q)
+	}
+
+	r, err := c.newRequest(ctx, http.MethodGet, fmt.Sprintf("/data/history/%s/transactions/%s?%s", c.Dataset, id, q.Encode()), nil)
+	if err != nil {
+		span.RecordError(ctx, err)
+		return nil, err
+	}
+
+	res, err := c.HTTPClient.Do(r)
+	if err != nil {
+		span.RecordError(ctx, err)
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := checkResponse(res); err != nil {
+		span.RecordError(ctx, err)
+		return nil, err
+	}
+
+	// the transactions endpoint responds with newline-delimited JSON
+	var txns []Transaction
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var txn Transaction
+		if err := json.Unmarshal([]byte(line), &txn); err != nil {
+			span.RecordError(ctx, err)
+			return nil, err
+		}
+		txns = append(txns, txn)
+	}
+	if err := scanner.Err(); err != nil {
+		span.RecordError(ctx, err)
+		return nil, err
+	}
+
+	span.SetAttributes(transactionCountKey(len(txns)))
+	return txns, nil
+}
@@ -0,0 +1,116 @@
+package mpsanity
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// redirectTransport sends every request to a test server instead of the Sanity API.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t *redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func newTestClient(t *testing.T, h http.HandlerFunc) *Client {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	target, err := url.Parse(srv.URL)
+	assert.NoError(t, err)
+
+	c, err := New("project", WithDataset("production"))
+	assert.NoError(t, err)
+	c.HTTPClient = &http.Client{Transport: &redirectTransport{target: target}}
+	return c
+}
+
+func TestDocAtRevision(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/data/history/production/documents/post-1", r.URL.Path)
+		assert.Equal(t, "rev-1", r.URL.Query().Get("revision"))
+		w.Write([]byte(`{"documents":[{"_id":"post-1","title":"Old title"}]}`))
+	})
+
+	var doc struct {
+		Title string `json:"title"`
+	}
+	assert.NoError(t, c.DocAtRevision(context.Background(), "post-1", "rev-1", &doc))
+	assert.Equal(t, "Old title", doc.Title)
+}
+
+func TestDocAtTimeNotFound(t *testing.T) {
+	ts := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2020-05-01T12:00:00Z", r.URL.Query().Get("time"))
+		w.Write([]byte(`{"documents":[]}`))
+	})
+	var doc map[string]interface{}
+	err := c.DocAtTime(context.Background(), "post-1", ts, &doc)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.Nil(t, doc)
+
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	err = c.DocAtTime(context.Background(), "post-1", ts, &doc)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestTransactions(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/data/history/production/transactions/post-1", r.URL.Path)
+		assert.Equal(t, url.Values{
+			"excludeContent": []string{"false"},
+			"reverse":        []string{"true"},
+			"limit":          []string{"2"},
+		}, r.URL.Query())
+
+		w.Write([]byte(`{"id":"tx-2","timestamp":"2020-05-02T00:00:00Z","author":"someone","documentIDs":["post-1"],"mutations":[{"patch":{"id":"post-1","set":{"title":"New"}}}]}
+{"id":"tx-1","timestamp":"2020-05-01T00:00:00Z","author":"someone","documentIDs":["post-1"],"mutations":[{"create":{"_id":"post-1"}}]}
+`))
+	})
+
+	txns, err := c.Transactions(context.Background(), "post-1", Reverse(), Limit(2))
+	assert.NoError(t, err)
+	assert.Len(t, txns, 2)
+	assert.Equal(t, "tx-2", txns[0].ID)
+	assert.Equal(t, time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC), txns[0].Timestamp)
+	assert.Equal(t, []string{"post-1"}, txns[0].DocumentIDs)
+	assert.JSONEq(t, `{"patch":{"id":"post-1","set":{"title":"New"}}}`, string(txns[0].Mutations[0]))
+	assert.Equal(t, "tx-1", txns[1].ID)
+}
+
+func TestTransactionsExcludeContent(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("excludeContent"))
+		w.Write([]byte(`{"id":"tx-1","timestamp":"2020-05-01T00:00:00Z","author":"someone","documentIDs":["post-1"]}` + "\n"))
+	})
+
+	txns, err := c.Transactions(context.Background(), "post-1", ExcludeContent())
+	assert.NoError(t, err)
+	assert.Len(t, txns, 1)
+	assert.Empty(t, txns[0].Mutations)
+}
+
+func TestTransactionsError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	_, err := c.Transactions(context.Background(), "post-1")
+	assert.EqualError(t, err, "unexpected client error 401")
+}
@@ -0,0 +1,99 @@
+package mpsanity
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const keyLength = 12
+
+const keyCharset = "0123456789abcdef"
+
+// KeyGenerator creates the _key values that Sanity requires on every object in an array.
+type KeyGenerator interface {
+	NewKey() string
+}
+
+type randomKeyGenerator struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewKeyGenerator returns a KeyGenerator that produces keys from the given seed. Two generators
+// created with the same seed produce the same sequence of keys, which is useful in tests.
+func NewKeyGenerator(seed int64) KeyGenerator {
+	return &randomKeyGenerator{
+		rnd: rand.New(rand.NewSource(seed)),
+	}
+}
+
+func newRandomKeyGenerator() KeyGenerator {
+	return NewKeyGenerator(time.Now().UnixNano())
+}
+
+func (g *randomKeyGenerator) NewKey() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	b := make([]byte, keyLength)
+	for i := range b {
+		b[i] = keyCharset[g.rnd.Intn(len(keyCharset))]
+	}
+	return string(b)
+}
+
+// AddKeys marshals v to JSON and adds a _key to every object inside an array that doesn't
+// already have one. Existing keys are left alone.
+func AddKeys(v interface{}, g KeyGenerator) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	addKeys(val, g)
+	return json.Marshal(val)
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var val interface{}
+	if err := d.Decode(&val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+func addKeys(val interface{}, g KeyGenerator) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		// visit fields in a fixed order so a seeded generator always assigns the same keys
+		fields := make([]string, 0, len(v))
+		for field := range v {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			addKeys(v[field], g)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				if key, ok := m["_key"].(string); !ok || key == "" {
+					m["_key"] = g.NewKey()
+				}
+			}
+			addKeys(item, g)
+		}
+	}
+}
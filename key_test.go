@@ -0,0 +1,99 @@
+package mpsanity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mjm/mpsanity/block"
+	"github.com/mjm/mpsanity/patch"
+)
+
+func TestAddKeys(t *testing.T) {
+	doc := struct {
+		Type        string        `json:"_type"`
+		Body        []block.Block `json:"body"`
+		Syndication []string      `json:"syndication"`
+	}{
+		Type: "post",
+		Body: []block.Block{
+			block.New("normal", block.Text("First")),
+			{
+				Type: "mainImage",
+				Key:  "existing",
+				Content: map[string]interface{}{
+					"alt": "Photo",
+				},
+			},
+		},
+		Syndication: []string{"https://example.com"},
+	}
+
+	data, err := AddKeys(doc, NewKeyGenerator(1))
+	assert.NoError(t, err)
+
+	var m struct {
+		Body []struct {
+			Key      string `json:"_key"`
+			Children []struct {
+				Key string `json:"_key"`
+			} `json:"children"`
+		} `json:"body"`
+		Syndication []string `json:"syndication"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &m))
+
+	assert.Len(t, m.Body[0].Key, keyLength)
+	assert.Len(t, m.Body[0].Children[0].Key, keyLength)
+	assert.NotEqual(t, m.Body[0].Key, m.Body[0].Children[0].Key)
+	assert.Equal(t, "existing", m.Body[1].Key)
+	assert.Equal(t, []string{"https://example.com"}, m.Syndication)
+
+	again, err := AddKeys(doc, NewKeyGenerator(1))
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(data), string(again))
+}
+
+func TestAddKeysDeterministic(t *testing.T) {
+	doc := map[string]interface{}{
+		"a": []map[string]interface{}{{}},
+		"b": []map[string]interface{}{{}},
+		"c": []map[string]interface{}{{}, {"nested": []map[string]interface{}{{}}}},
+		"d": map[string]interface{}{
+			"e": []map[string]interface{}{{}},
+		},
+	}
+
+	first, err := AddKeys(doc, NewKeyGenerator(1))
+	assert.NoError(t, err)
+
+	// map iteration order changes between runs, so a few tries are enough to catch it
+	for i := 0; i < 20; i++ {
+		again, err := AddKeys(doc, NewKeyGenerator(1))
+		assert.NoError(t, err)
+		assert.Equal(t, string(first), string(again))
+	}
+}
+
+func TestTxnAddsKeysToPatchItems(t *testing.T) {
+	c, err := New("project", WithKeySeed(1))
+	assert.NoError(t, err)
+
+	txn := c.Txn().Patch("doc-id",
+		patch.InsertAfter("body[-1]", block.New("normal", block.Text("Appended"))))
+
+	data, err := txn.marshalMutations()
+	assert.NoError(t, err)
+
+	var req struct {
+		Mutations []map[string]interface{} `json:"mutations"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &req))
+
+	assert.Len(t, req.Mutations, 1)
+	assert.NotContains(t, req.Mutations[0], "_key")
+
+	items := req.Mutations[0]["patch"].(map[string]interface{})["insert"].(map[string]interface{})["items"].([]interface{})
+	assert.Len(t, items[0].(map[string]interface{})["_key"], keyLength)
+}
@@ -18,11 +18,11 @@ func (in *CreateInput) Name() string {
 	return ""
 }
 
-func (in *CreateInput) Content() string {
+func (in *CreateInput) Content() Content {
 	if vs := in.Props.Content; len(vs) > 0 {
 		return vs[0]
 	}
-	return ""
+	return Content{}
 }
 
 func (in *CreateInput) Slug() string {
@@ -50,3 +50,14 @@ func (in *CreateInput) Photos() []mpsanity.Reference {
 func (in *CreateInput) Syndication() []string {
 	return in.Props.Syndication
 }
+
+func (in *CreateInput) Categories() []string {
+	return in.Props.Category
+}
+
+func (in *CreateInput) Summary() string {
+	if vs := in.Props.Summary; len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
@@ -2,6 +2,9 @@ package mpapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gosimple/slug"
@@ -13,6 +16,9 @@ import (
 	"github.com/mjm/mpsanity/patch"
 )
 
+// slugWords is how many words of a post's content are used for its slug when it has no title.
+const slugWords = 8
+
 var ErrNotEntry = status.Error(codes.InvalidArgument, "post is not an entry")
 
 type DocumentBuilder interface {
@@ -22,20 +28,30 @@ type DocumentBuilder interface {
 
 type DefaultDocumentBuilder struct {
 	MarkdownConverter *block.MarkdownConverter
+	// HTMLConverter converts content that is sent as HTML. Without one, HTML content is rejected.
+	HTMLConverter *block.HTMLConverter
 }
 
-func (d *DefaultDocumentBuilder) BuildDocument(_ context.Context, input *CreateInput) (Document, error) {
+func (d *DefaultDocumentBuilder) BuildDocument(ctx context.Context, input *CreateInput) (Document, error) {
 	if input.Type[0] != "entry" {
 		return nil, ErrNotEntry
 	}
 
 	var doc defaultDocument
 
-	if slug := input.Slug(); slug != "" {
+	// front matter in the content fills in any properties that weren't given explicitly
+	content := input.Content()
+	fm, text, err := block.SplitFrontMatter(content.Text)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	content.Text = text
+
+	if slug := firstString(input.Slug(), fm.String("slug")); slug != "" {
 		doc.Slug = mpsanity.Slug(slug)
 	}
 
-	if name := input.Name(); name != "" {
+	if name := firstString(input.Name(), fm.String("title")); name != "" {
 		doc.Type = "post"
 		doc.Title = name
 		if doc.Slug == "" {
@@ -45,15 +61,15 @@ func (d *DefaultDocumentBuilder) BuildDocument(_ context.Context, input *CreateI
 		doc.Type = "micropost"
 	}
 
-	if content := input.Content(); content != "" {
-		out, err := d.MarkdownConverter.ToBlocks(content)
+	if content.Text != "" || content.HTML != "" {
+		out, err := d.toBlocks(ctx, content)
 		if err != nil {
 			return nil, err
 		}
 		doc.Body = out
 
 		if doc.Slug == "" {
-			doc.Slug = mpsanity.Slug(slug.Make(block.ToPlainText(doc.Body)))
+			doc.Slug = mpsanity.Slug(slug.Make(block.ToPlainText(doc.Body, block.WithMaxWords(slugWords))))
 		}
 	}
 
@@ -61,18 +77,21 @@ func (d *DefaultDocumentBuilder) BuildDocument(_ context.Context, input *CreateI
 		doc.Slug = mpsanity.Slug(randomString(10))
 	}
 
-	for _, photo := range input.Photos() {
+	for i, photo := range input.Photos() {
 		doc.Body = append(doc.Body, block.Block{
-			Type: "mainImage",
-			Content: map[string]interface{}{
-				"alt":   "Photo",
-				"asset": photo,
+			Type: block.TypeMainImage,
+			Key:  photoKey(i),
+			Content: &block.ImageContent{
+				Alt:   "Photo",
+				Asset: block.Reference(photo),
 			},
 		})
 	}
 
 	if pub := input.Published(); pub != nil {
 		doc.PublishedAt = *pub
+	} else if pub, ok := fm.Time("date", "published"); ok {
+		doc.PublishedAt = pub
 	} else {
 		doc.PublishedAt = time.Now()
 	}
@@ -80,6 +99,11 @@ func (d *DefaultDocumentBuilder) BuildDocument(_ context.Context, input *CreateI
 	doc.Slug = mpsanity.Slug(doc.PublishedAt.Format("2006-01-02") + "-" + string(doc.Slug))
 
 	doc.Syndication = input.Syndication()
+	doc.Summary = firstString(input.Summary(), fm.String("summary", "description"))
+	doc.Tags = input.Categories()
+	if len(doc.Tags) == 0 {
+		doc.Tags = fm.Strings("tags", "categories")
+	}
 
 	return &doc, nil
 }
@@ -87,10 +111,45 @@ func (d *DefaultDocumentBuilder) BuildDocument(_ context.Context, input *CreateI
 func (d *DefaultDocumentBuilder) UpdateDocument(ctx context.Context, input *UpdateInput) ([]patch.Patch, error) {
 	var ps []patch.Patch
 
-	if len(input.Replace.Name) > 0 {
-		ps = append(ps, patch.Set("title", input.Replace.Name[0]))
+	var fm block.FrontMatter
+	if len(input.Replace.Content) > 0 {
+		content := input.Replace.Content[0]
+		var text string
+		var err error
+		fm, text, err = block.SplitFrontMatter(content.Text)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		content.Text = text
+
+		body, err := d.toBlocks(ctx, content)
+		if err != nil {
+			return nil, err
+		}
+
+		// photos are stored in the body too, so leave them alone. Images from the content can
+		// change like anything else in it.
+		ps = append(ps, block.Diff("body", input.Body, body, block.WithKept(isPhoto))...)
+	}
+
+	// front matter in the new content updates any properties that aren't being replaced
+	// explicitly. The slug is left alone so the post's URL doesn't change.
+	if name := firstString(firstOf(input.Replace.Name), fm.String("title")); name != "" {
+		ps = append(ps, patch.Set("title", name))
+	}
+	if len(input.Replace.Published) > 0 {
+		ps = append(ps, patch.Set("publishedAt", input.Replace.Published[0]))
+	} else if pub, ok := fm.Time("date", "published"); ok {
+		ps = append(ps, patch.Set("publishedAt", pub))
+	}
+	if summary := firstString(firstOf(input.Replace.Summary), fm.String("summary", "description")); summary != "" {
+		ps = append(ps, patch.Set("summary", summary))
+	}
+	if tags := input.Replace.Category; len(tags) > 0 {
+		ps = append(ps, patch.Set("tags", tags))
+	} else if tags := fm.Strings("tags", "categories"); len(tags) > 0 {
+		ps = append(ps, patch.Set("tags", tags))
 	}
-	// TODO update content (this is kinda hard when photos are stored inside it)
 	if len(input.Replace.Syndication) > 0 {
 		ps = append(ps, patch.Set("syndication", input.Replace.Syndication))
 	}
@@ -115,6 +174,37 @@ type defaultDocument struct {
 	Slug        mpsanity.Slug `json:"slug"`
 	PublishedAt time.Time     `json:"publishedAt"`
 	Syndication []string      `json:"syndication,omitempty"`
+	Summary     string        `json:"summary,omitempty"`
+	Tags        []string      `json:"tags,omitempty"`
+}
+
+func firstString(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// photoKeyPrefix starts the keys of the image blocks for a post's photos, to tell them apart
+// from images in its content.
+const photoKeyPrefix = "photo-"
+
+func photoKey(i int) string {
+	return fmt.Sprintf("%s%d", photoKeyPrefix, i)
+}
+
+func isPhoto(b block.Block) bool {
+	return b.Type == block.TypeMainImage && strings.HasPrefix(b.Key, photoKeyPrefix)
+}
+
+// firstOf returns the first value of a property, or "" if it has none.
+func firstOf(values []string) string {
+	if len(values) > 0 {
+		return values[0]
+	}
+	return ""
 }
 
 type Document interface {
@@ -124,3 +214,26 @@ type Document interface {
 func (d *defaultDocument) URLPath() string {
 	return "/" + string(d.Slug)
 }
+
+// toBlocks converts a post's content from HTML if it has any, or else from Markdown. Content that
+// can't be turned into blocks is a problem with the request.
+func (d *DefaultDocumentBuilder) toBlocks(ctx context.Context, content Content) ([]block.Block, error) {
+	var bs []block.Block
+	var err error
+	if content.HTML != "" {
+		if d.HTMLConverter == nil {
+			return nil, status.Error(codes.InvalidArgument, "html content is not supported")
+		}
+		bs, err = d.HTMLConverter.ToBlocksContext(ctx, content.HTML)
+	} else {
+		bs, err = d.MarkdownConverter.ToBlocksContext(ctx, content.Text)
+	}
+	if err != nil {
+		var buildErrs block.BuildErrors
+		if errors.As(err, &buildErrs) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, err
+	}
+	return bs, nil
+}
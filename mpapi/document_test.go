@@ -0,0 +1,105 @@
+package mpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mjm/mpsanity/block"
+	"github.com/mjm/mpsanity/patch"
+)
+
+func newTestBuilder() *DefaultDocumentBuilder {
+	images := block.ImageResolverFunc(func(ctx context.Context, src string) (block.Reference, error) {
+		return block.Reference("image-" + strings.TrimPrefix(src, "https://example.com/")), nil
+	})
+	return &DefaultDocumentBuilder{
+		MarkdownConverter: block.NewMarkdownConverter(block.WithImageResolver(images)),
+		HTMLConverter:     block.NewHTMLConverter(block.WithHTMLImageResolver(images)),
+	}
+}
+
+// storeBody gives the blocks of a body keys, like Sanity does when the document is saved.
+func storeBody(body []block.Block) []block.Block {
+	for i := range body {
+		if body[i].Key == "" {
+			body[i].Key = fmt.Sprintf("key%d", i)
+		}
+	}
+	return body
+}
+
+// applyPatches applies patches to a body the way the Sanity API would, and returns the result.
+func applyPatches(t *testing.T, body []block.Block, ps []patch.Patch) []block.Block {
+	data, err := json.Marshal(map[string]interface{}{"body": body})
+	assert.NoError(t, err)
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &doc))
+
+	for _, group := range patch.Split(ps...) {
+		p := &patch.Description{}
+		for _, patcher := range group {
+			patcher.Apply(p)
+		}
+		assert.NoError(t, p.ApplyTo(doc))
+	}
+
+	data, err = json.Marshal(doc["body"])
+	assert.NoError(t, err)
+	var out []block.Block
+	assert.NoError(t, json.Unmarshal(data, &out))
+	return out
+}
+
+func imageAssets(body []block.Block) []string {
+	var assets []string
+	for _, b := range body {
+		if ic, ok := b.Content.(*block.ImageContent); ok {
+			assets = append(assets, string(ic.Asset))
+		}
+	}
+	return assets
+}
+
+func TestUpdateDocumentChangesImages(t *testing.T) {
+	d := newTestBuilder()
+	ctx := context.Background()
+
+	doc, err := d.BuildDocument(ctx, &CreateInput{
+		Type: []string{"entry"},
+		Props: Props{
+			Content: []Content{{Text: "Some text.\n\n![A picture](https://example.com/old-png)"}},
+			Photo:   []string{"image-photo-jpg"},
+		},
+	})
+	assert.NoError(t, err)
+	body := storeBody(doc.(*defaultDocument).Body)
+	assert.Equal(t, []string{"image-old-png", "image-photo-jpg"}, imageAssets(body))
+
+	// changing the image in the content replaces it, and the photo stays
+	ps, err := d.UpdateDocument(ctx, &UpdateInput{
+		Replace: Props{
+			Content: []Content{{Text: "Some text.\n\n![A picture](https://example.com/new-png)"}},
+		},
+		Body: body,
+	})
+	assert.NoError(t, err)
+	body = applyPatches(t, body, ps)
+	assert.Equal(t, []string{"image-new-png", "image-photo-jpg"}, imageAssets(body))
+
+	// removing it from the content removes it
+	ps, err = d.UpdateDocument(ctx, &UpdateInput{
+		Replace: Props{
+			Content: []Content{{Text: "Some text."}},
+		},
+		Body: body,
+	})
+	assert.NoError(t, err)
+	body = applyPatches(t, body, ps)
+	assert.Equal(t, []string{"image-photo-jpg"}, imageAssets(body))
+	assert.Len(t, body, 2)
+}
@@ -66,8 +66,12 @@ func (h *MicropubHandler) readInputFromForm(r *http.Request) (*CreateInput, erro
 	input.Type = r.Form["h"]
 	span.SetAttributes(typeKey(input.Type[0]))
 	input.Props.Name = r.Form["name"]
-	input.Props.Content = r.Form["content"]
+	for _, content := range r.Form["content"] {
+		input.Props.Content = append(input.Props.Content, Content{Text: content})
+	}
 	input.Props.Slug = r.Form["mp-slug"]
+	input.Props.Summary = r.Form["summary"]
+	input.Props.Category = append(r.Form["category"], r.Form["category[]"]...)
 
 	for _, pub := range r.Form["published"] {
 		t, err := time.Parse(time.RFC3339, pub)
@@ -10,6 +10,8 @@ import (
 	"go.opentelemetry.io/otel/api/key"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/mjm/mpsanity/patch"
 )
 
 var (
@@ -60,18 +62,30 @@ func (h *MicropubHandler) handleMicropubJSON(w http.ResponseWriter, r *http.Requ
 			respondWithError(ctx, w, ErrWrongBase)
 		}
 
+		// TODO maybe move query construction into document builder
+		slug := strings.TrimPrefix(strings.TrimSuffix(input.URL, "/"), h.baseURL+"/")
+		span.SetAttributes(slugKey(slug))
+		q := fmt.Sprintf(`*[slug.current == %q]`, slug)
+		span.SetAttributes(key.String("sanity.query", q))
+
+		if len(input.Replace.Content) > 0 {
+			if err := h.Sanity.Query(ctx, q+"[0].body", &input.Body); err != nil {
+				respondWithError(ctx, w, err)
+				return
+			}
+		}
+
 		patches, err := h.docBuilder.UpdateDocument(ctx, &input)
 		if err != nil {
 			respondWithError(ctx, w, err)
 			return
 		}
 
-		// TODO maybe move query construction into document builder
-		slug := strings.TrimPrefix(strings.TrimSuffix(input.URL, "/"), h.baseURL+"/")
-		span.SetAttributes(slugKey(slug))
-		q := fmt.Sprintf(`*[slug.current == %q]`, slug)
-		span.SetAttributes(key.String("sanity.query", q))
-		if err := h.Sanity.Txn().PatchQuery(q, patches...).Commit(ctx); err != nil {
+		txn := h.Sanity.Txn()
+		for _, group := range patch.Split(patches...) {
+			txn.PatchQuery(q, group...)
+		}
+		if err := txn.Commit(ctx); err != nil {
 			respondWithError(ctx, w, err)
 			return
 		}
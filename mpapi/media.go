@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 
 	"go.opentelemetry.io/otel/api/key"
 	"go.opentelemetry.io/otel/api/trace"
@@ -42,7 +41,7 @@ func (h *MicropubHandler) handleMedia(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	imgURL := h.urlForImageAsset(imgIDs[0])
+	imgURL := h.Sanity.ImageURL(imgIDs[0])
 	w.Header().Set("Location", imgURL)
 	w.WriteHeader(http.StatusCreated)
 }
@@ -113,12 +112,3 @@ func (h *MicropubHandler) uploadImageAssets(ctx context.Context, rs []io.ReadClo
 
 	return imgIDs, nil
 }
-
-func (h *MicropubHandler) urlForImageAsset(id string) string {
-	urlID := strings.TrimPrefix(id, "image-")
-	if lastDashIdx := strings.LastIndex(urlID, "-"); lastDashIdx != -1 {
-		urlID = urlID[:lastDashIdx] + "." + urlID[lastDashIdx+1:]
-	}
-
-	return fmt.Sprintf("https://cdn.sanity.io/images/%s/%s/%s", h.Sanity.ProjectID, h.Sanity.Dataset, urlID)
-}
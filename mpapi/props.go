@@ -1,14 +1,42 @@
 package mpapi
 
 import (
+	"encoding/json"
 	"time"
 )
 
 type Props struct {
 	Name        []string    `json:"name,omitempty"`
-	Content     []string    `json:"content,omitempty"`
+	Content     []Content   `json:"content,omitempty"`
 	Slug        []string    `json:"mp-slug"`
 	Published   []time.Time `json:"published"`
 	Photo       []string    `json:"photo"`
 	Syndication []string    `json:"syndication"`
+	Category    []string    `json:"category"`
+	Summary     []string    `json:"summary"`
+}
+
+// Content is a value of the content property. Clients send either a plain string, which is
+// treated as Markdown, or an object with the content as HTML.
+type Content struct {
+	Text string
+	HTML string
+}
+
+func (c *Content) UnmarshalJSON(b []byte) error {
+	*c = Content{}
+	if err := json.Unmarshal(b, &c.Text); err == nil {
+		return nil
+	}
+
+	var obj struct {
+		Value string `json:"value"`
+		HTML  string `json:"html"`
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return err
+	}
+	c.Text = obj.Value
+	c.HTML = obj.HTML
+	return nil
 }
@@ -0,0 +1,128 @@
+package mpapi
+
+import (
+	"github.com/mjm/mpsanity/block"
+	"github.com/mjm/mpsanity/schema"
+)
+
+// DefaultSchema describes the documents created by DefaultDocumentBuilder.
+var DefaultSchema = schema.New(
+	schema.Document("post", "Post",
+		schema.String("title", schema.Title("Title"), schema.Required()),
+		schema.Slug("slug", schema.Title("Slug"), schema.Required()),
+		schema.Datetime("publishedAt", schema.Title("Published at"), schema.Required()),
+		schema.Text("summary", schema.Title("Summary")),
+		bodyField(),
+		syndicationField(),
+		tagsField()),
+	schema.Document("micropost", "Micropost",
+		schema.Slug("slug", schema.Title("Slug"), schema.Required()),
+		schema.Datetime("publishedAt", schema.Title("Published at"), schema.Required()),
+		schema.Text("summary", schema.Title("Summary")),
+		bodyField(),
+		syndicationField(),
+		tagsField()),
+	schema.ImageObject(block.TypeMainImage, "Image",
+		schema.String("alt", schema.Title("Alternative text")),
+		schema.String("caption", schema.Title("Caption"))),
+	schema.Object(block.TypeCode, "Code",
+		schema.String("language", schema.Title("Language")),
+		schema.String("filename", schema.Title("Filename")),
+		schema.Array("highlightedLines", schema.Title("Highlighted lines"), schema.Of(schema.Number(""))),
+		schema.Array("attributes",
+			schema.Title("Attributes"),
+			schema.Of(
+				schema.InlineObject("codeAttribute",
+					schema.Title("Attribute"),
+					schema.Fields(
+						schema.String("key", schema.Title("Key"), schema.Required()),
+						schema.String("value", schema.Title("Value")))))),
+		schema.Boolean("indented", schema.Title("Indented")),
+		schema.Text("code", schema.Title("Code"))),
+	embedObject(block.TypeTweet, "Tweet"),
+	embedObject(block.TypeYouTube, "YouTube"),
+	embedObject(block.TypeVimeo, "Vimeo"),
+	embedObject(block.TypeInstagram, "Instagram"),
+	embedObject(block.TypeMastodon, "Mastodon"),
+	embedObject(block.TypeGist, "Gist"),
+	embedObject(block.TypeCodePen, "CodePen"),
+	embedObject(block.TypeSpotify, "Spotify"),
+	embedObject(block.TypeBluesky, "Bluesky"),
+	schema.Object(block.TypeMention, "Mention",
+		schema.String("username", schema.Title("Username"), schema.Required()),
+		schema.String("instance", schema.Title("Instance"), schema.Required()),
+		schema.URL("url", schema.Title("URL"))),
+	schema.Object(block.TypeTable, "Table",
+		schema.Array("rows",
+			schema.Title("Rows"),
+			schema.Of(
+				schema.InlineObject("tableRow",
+					schema.Title("Row"),
+					schema.Fields(
+						schema.Boolean("header", schema.Title("Header")),
+						schema.Array("cells", schema.Title("Cells"), schema.Of(schema.String("")))))))),
+	schema.Object(block.TypeBreak, "Break",
+		schema.String("style", schema.Title("Style"))),
+)
+
+func bodyField() *schema.Field {
+	return schema.Array("body",
+		schema.Title("Body"),
+		schema.Of(
+			textBlock(schema.Annotations(
+				schema.InlineObject(block.MarkFootnote,
+					schema.Title("Footnote"),
+					schema.Fields(
+						schema.Number("number", schema.Title("Number")),
+						schema.Array("text", schema.Title("Text"), schema.Of(textBlock())))))),
+			schema.Member(block.TypeMainImage),
+			schema.Member(block.TypeCode),
+			schema.Member(block.TypeTweet),
+			schema.Member(block.TypeYouTube),
+			schema.Member(block.TypeVimeo),
+			schema.Member(block.TypeInstagram),
+			schema.Member(block.TypeMastodon),
+			schema.Member(block.TypeGist),
+			schema.Member(block.TypeCodePen),
+			schema.Member(block.TypeSpotify),
+			schema.Member(block.TypeBluesky),
+			schema.Member(block.TypeTable),
+			schema.Member(block.TypeBreak)))
+}
+
+func textBlock(opts ...schema.FieldOption) *schema.Field {
+	opts = append([]schema.FieldOption{
+		schema.Styles("normal", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote"),
+		schema.Lists("bullet", "number"),
+		schema.Of(schema.Member(block.TypeMention)),
+		schema.Decorators("strong", "em", "code", "strike-through", "highlight", "underline", "sup", "sub"),
+		schema.Annotations(
+			schema.InlineObject("link",
+				schema.Title("URL"),
+				schema.Fields(schema.URL("href", schema.Title("URL")))),
+			schema.InlineObject(block.MarkInternalLink,
+				schema.Title("Internal link"),
+				schema.Fields(schema.Reference("reference",
+					schema.Title("Reference"),
+					schema.To("post", "micropost"))))),
+	}, opts...)
+	return schema.Block(opts...)
+}
+
+func embedObject(name, title string) *schema.Type {
+	return schema.Object(name, title,
+		schema.URL("url", schema.Title("URL"), schema.Required()),
+		schema.String("id", schema.Title("ID")))
+}
+
+func tagsField() *schema.Field {
+	return schema.Array("tags",
+		schema.Title("Tags"),
+		schema.Of(schema.String("")))
+}
+
+func syndicationField() *schema.Field {
+	return schema.Array("syndication",
+		schema.Title("Syndication"),
+		schema.Of(schema.URL("")))
+}
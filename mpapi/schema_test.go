@@ -0,0 +1,71 @@
+package mpapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultSchemaValidatesDocuments(t *testing.T) {
+	d := newTestBuilder()
+	ctx := context.Background()
+
+	content := "Some **text** with a [link](https://example.com) and a note.[^1]\n\n" +
+		"* One\n* Two\n\n" +
+		"> A quote\n\n" +
+		"```go {2}\npackage main\n\nfunc main() {}\n```\n\n" +
+		"| A | B |\n| --- | --- |\n| 1 | 2 |\n\n" +
+		"---\n\n" +
+		"![A picture](https://example.com/picture-png \"A caption\")\n\n" +
+		"[^1]: The _note_."
+
+	cases := []struct {
+		name  string
+		input *CreateInput
+	}{
+		{
+			name: "post",
+			input: &CreateInput{
+				Type: []string{"entry"},
+				Props: Props{
+					Name:        []string{"A post"},
+					Content:     []Content{{Text: content}},
+					Summary:     []string{"A summary"},
+					Category:    []string{"go"},
+					Syndication: []string{"https://twitter.com/some_user/status/1"},
+					Photo:       []string{"image-photo-jpg"},
+				},
+			},
+		},
+		{
+			name: "micropost",
+			input: &CreateInput{
+				Type: []string{"entry"},
+				Props: Props{
+					Content: []Content{{Text: "Just some text."}},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc, err := d.BuildDocument(ctx, c.input)
+			assert.NoError(t, err)
+
+			data, err := json.Marshal(doc)
+			assert.NoError(t, err)
+			var m map[string]interface{}
+			assert.NoError(t, json.Unmarshal(data, &m))
+			m["_id"] = "abc"
+
+			assert.NoError(t, DefaultSchema.Validate(m))
+
+			// make sure the schema really checks the body
+			m["body"] = append(m["body"].([]interface{}), map[string]interface{}{"_type": "unknown", "_key": "x"})
+			assert.Error(t, DefaultSchema.Validate(m))
+		})
+	}
+}
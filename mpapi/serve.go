@@ -23,6 +23,7 @@ func New(sanity *mpsanity.Client, opts ...Option) *MicropubHandler {
 		Sanity: sanity,
 		docBuilder: &DefaultDocumentBuilder{
 			MarkdownConverter: block.NewMarkdownConverter(),
+			HTMLConverter:     block.NewHTMLConverter(),
 		},
 		mux: http.NewServeMux(),
 	}
@@ -2,6 +2,8 @@ package mpapi
 
 import (
 	"encoding/json"
+
+	"github.com/mjm/mpsanity/block"
 )
 
 type UpdateInput struct {
@@ -10,6 +12,10 @@ type UpdateInput struct {
 	Add     Props  `json:"add"`
 	// Delete is either a []string or a Props
 	Delete interface{} `json:"delete"`
+
+	// Body is the document's current body, which is loaded before updating when the content is
+	// being replaced.
+	Body []block.Block `json:"-"`
 }
 
 // use a new type to get the standard unmarshalling behavior
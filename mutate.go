@@ -108,35 +108,30 @@ func (t *Txn) Commit(ctx context.Context) error {
 			mutationCountKey(len(t.mutations))))
 	defer span.End()
 
-	m := mutationRequest{Mutations: t.mutations}
-	body, err := json.Marshal(m)
-	if err != nil {
+	if err := t.validate(ctx); err != nil {
 		span.RecordError(ctx, err)
 		return err
 	}
 
-	r, err := c.newRequest(ctx, http.MethodPost, fmt.Sprintf("/data/mutate/%s", c.Dataset), bytes.NewReader(body))
+	body, err := t.marshalMutations()
 	if err != nil {
 		span.RecordError(ctx, err)
 		return err
 	}
 
-	res, err := c.HTTPClient.Do(r)
+	r, err := c.newRequest(ctx, http.MethodPost, fmt.Sprintf("/data/mutate/%s", c.Dataset), bytes.NewReader(body))
 	if err != nil {
 		span.RecordError(ctx, err)
 		return err
 	}
 
-	if res.StatusCode >= 500 {
-		// TODO parse messages out of error response
-		err = fmt.Errorf("unexpected server error %d", res.StatusCode)
+	res, err := c.HTTPClient.Do(r)
+	if err != nil {
 		span.RecordError(ctx, err)
 		return err
 	}
 
-	if res.StatusCode >= 400 {
-		// TODO parse messages out of error response
-		err = fmt.Errorf("unexpected client error %d", res.StatusCode)
+	if err := checkResponse(res); err != nil {
 		span.RecordError(ctx, err)
 		return err
 	}
@@ -144,6 +139,27 @@ func (t *Txn) Commit(ctx context.Context) error {
 	return nil
 }
 
+func (t *Txn) marshalMutations() ([]byte, error) {
+	if t.client.Keys == nil {
+		return json.Marshal(mutationRequest{Mutations: t.mutations})
+	}
+
+	ms := make([]json.RawMessage, 0, len(t.mutations))
+	for _, m := range t.mutations {
+		data, err := AddKeys(m, t.client.Keys)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, data)
+	}
+
+	return json.Marshal(keyedMutationRequest{Mutations: ms})
+}
+
 type mutationRequest struct {
 	Mutations []mutation `json:"mutations"`
 }
+
+type keyedMutationRequest struct {
+	Mutations []json.RawMessage `json:"mutations"`
+}
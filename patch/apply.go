@@ -0,0 +1,392 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadPath    = errors.New("invalid patch path")
+	ErrNotArray   = errors.New("value is not an array")
+	ErrNotObject  = errors.New("value is not an object")
+	ErrNotNumber  = errors.New("value is not a number")
+	ErrNoSuchItem = errors.New("no matching array item")
+)
+
+// ApplyTo applies the patch to a document that has been decoded from JSON into generic maps and
+// slices, the same way the Sanity API would apply it. Diff-match-patch operations are not
+// supported and are ignored.
+//
+// Paths within each operation are applied in sorted order, the order they're serialized in, so
+// overlapping paths always give the same result.
+func (p *Description) ApplyTo(doc map[string]interface{}) error {
+	for _, path := range sortedPaths(p.Set) {
+		v, err := normalize(p.Set[path])
+		if err != nil {
+			return err
+		}
+		if err := modify(doc, path, true, func(interface{}, bool) (interface{}, bool) {
+			return v, true
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range sortedPaths(p.SetIfMissing) {
+		v, err := normalize(p.SetIfMissing[path])
+		if err != nil {
+			return err
+		}
+		if err := modify(doc, path, true, func(cur interface{}, exists bool) (interface{}, bool) {
+			if exists && cur != nil {
+				return cur, true
+			}
+			return v, true
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range p.Unset {
+		if err := modify(doc, path, false, func(interface{}, bool) (interface{}, bool) {
+			return nil, false
+		}); err != nil {
+			return err
+		}
+	}
+
+	if p.Insert != nil {
+		if err := p.Insert.applyTo(doc); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range sortedPaths(p.Inc) {
+		if err := addNumber(doc, path, p.Inc[path], 1); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range sortedPaths(p.Dec) {
+		if err := addNumber(doc, path, p.Dec[path], -1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ins *insertion) applyTo(doc map[string]interface{}) error {
+	var path string
+	switch {
+	case ins.Before != "":
+		path = ins.Before
+	case ins.After != "":
+		path = ins.After
+	default:
+		path = ins.Replace
+	}
+
+	segs, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	last := segs[len(segs)-1]
+	if len(segs) < 2 || last.field != "" {
+		return fmt.Errorf("%w: %q does not refer to an array item", ErrBadPath, path)
+	}
+
+	items := make([]interface{}, 0, len(ins.Items))
+	for _, item := range ins.Items {
+		v, err := normalize(item)
+		if err != nil {
+			return err
+		}
+		items = append(items, v)
+	}
+
+	var insertErr error
+	_, err = modifySegments(doc, segs[:len(segs)-1], true, func(cur interface{}, exists bool) (interface{}, bool) {
+		arr, ok := cur.([]interface{})
+		if exists && cur != nil && !ok {
+			insertErr = fmt.Errorf("%s %w", path, ErrNotArray)
+			return cur, true
+		}
+
+		idx, found := last.index(arr)
+		var start, end int
+		switch {
+		case ins.Before != "":
+			start, end = idx, idx
+		case ins.After != "":
+			start, end = idx+1, idx+1
+		default:
+			if !found {
+				insertErr = fmt.Errorf("%s: %w", path, ErrNoSuchItem)
+				return cur, true
+			}
+			start, end = idx, idx+1
+		}
+		if !found && last.key != "" {
+			insertErr = fmt.Errorf("%s: %w", path, ErrNoSuchItem)
+			return cur, true
+		}
+		start = clamp(start, 0, len(arr))
+		end = clamp(end, 0, len(arr))
+
+		newArr := make([]interface{}, 0, len(arr)+len(items))
+		newArr = append(newArr, arr[:start]...)
+		newArr = append(newArr, items...)
+		newArr = append(newArr, arr[end:]...)
+		return newArr, true
+	})
+	if err != nil {
+		return err
+	}
+	return insertErr
+}
+
+func addNumber(doc map[string]interface{}, path string, val interface{}, sign float64) error {
+	delta, ok := toFloat(val)
+	if !ok {
+		return fmt.Errorf("%v %w", val, ErrNotNumber)
+	}
+
+	var numErr error
+	err := modify(doc, path, false, func(cur interface{}, exists bool) (interface{}, bool) {
+		if !exists {
+			return cur, false
+		}
+		n, ok := toFloat(cur)
+		if !ok {
+			numErr = fmt.Errorf("%s %w", path, ErrNotNumber)
+			return cur, true
+		}
+		return n + sign*delta, true
+	})
+	if err != nil {
+		return err
+	}
+	return numErr
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func clamp(n, min, max int) int {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// normalize converts a Go value into the generic form it would have after a JSON round-trip.
+func normalize(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func sortedPaths(m map[string]interface{}) []string {
+	paths := make([]string, 0, len(m))
+	for path := range m {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+type modifyFn func(cur interface{}, exists bool) (interface{}, bool)
+
+// modify changes the value at path with fn. If create is false, missing objects along the path
+// are left missing instead of being created.
+func modify(doc map[string]interface{}, path string, create bool, fn modifyFn) error {
+	segs, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = modifySegments(doc, segs, create, fn)
+	return err
+}
+
+func modifySegments(val interface{}, segs []segment, create bool, fn modifyFn) (interface{}, error) {
+	if len(segs) == 0 {
+		v, _ := fn(val, true)
+		return v, nil
+	}
+
+	seg, rest := segs[0], segs[1:]
+
+	if seg.field != "" {
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			if val != nil {
+				return nil, fmt.Errorf("%s: %w", seg.field, ErrNotObject)
+			}
+			m = make(map[string]interface{})
+		}
+
+		cur, exists := m[seg.field]
+		if len(rest) == 0 {
+			if v, keep := fn(cur, exists); keep {
+				m[seg.field] = v
+			} else {
+				delete(m, seg.field)
+			}
+			return m, nil
+		}
+
+		if cur == nil && !create {
+			return m, nil
+		}
+
+		v, err := modifySegments(cur, rest, create, fn)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil || exists {
+			m[seg.field] = v
+		}
+		return m, nil
+	}
+
+	arr, ok := val.([]interface{})
+	if !ok && val != nil {
+		return nil, ErrNotArray
+	}
+
+	idx, found := seg.index(arr)
+	if !found {
+		return val, nil
+	}
+
+	if len(rest) == 0 {
+		if v, keep := fn(arr[idx], true); keep {
+			arr[idx] = v
+		} else {
+			arr = append(arr[:idx], arr[idx+1:]...)
+		}
+		return arr, nil
+	}
+
+	if arr[idx] == nil && !create {
+		return arr, nil
+	}
+
+	v, err := modifySegments(arr[idx], rest, create, fn)
+	if err != nil {
+		return nil, err
+	}
+	arr[idx] = v
+	return arr, nil
+}
+
+// segment is one step in a patch path: either an object field, an array index, or an array item
+// matched by its _key.
+type segment struct {
+	field string
+	idx   int
+	key   string
+}
+
+func (s segment) index(arr []interface{}) (int, bool) {
+	if s.key != "" {
+		for i, item := range arr {
+			if m, ok := item.(map[string]interface{}); ok && m["_key"] == s.key {
+				return i, true
+			}
+		}
+		return -1, false
+	}
+
+	i := s.idx
+	if i < 0 {
+		i += len(arr)
+	}
+	return i, i >= 0 && i < len(arr)
+}
+
+func parsePath(path string) ([]segment, error) {
+	var segs []segment
+	rest := path
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("%w: %q", ErrBadPath, path)
+			}
+			seg, err := parseSelector(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", err, path)
+			}
+			segs = append(segs, seg)
+			rest = rest[end+1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			segs = append(segs, segment{field: rest[:end]})
+			rest = rest[end:]
+		}
+	}
+
+	if len(segs) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrBadPath, path)
+	}
+	return segs, nil
+}
+
+func parseSelector(sel string) (segment, error) {
+	sel = strings.TrimSpace(sel)
+	if strings.HasPrefix(sel, "_key") {
+		val := strings.TrimSpace(strings.TrimPrefix(sel, "_key"))
+		if !strings.HasPrefix(val, "==") {
+			return segment{}, ErrBadPath
+		}
+		val = strings.TrimSpace(strings.TrimPrefix(val, "=="))
+		if len(val) < 2 || (val[0] != '"' && val[0] != '\'') || val[len(val)-1] != val[0] {
+			return segment{}, ErrBadPath
+		}
+		return segment{key: val[1 : len(val)-1]}, nil
+	}
+
+	i, err := strconv.Atoi(sel)
+	if err != nil {
+		return segment{}, ErrBadPath
+	}
+	return segment{idx: i}, nil
+}
@@ -0,0 +1,133 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyTo(t *testing.T) {
+	newDoc := func() map[string]interface{} {
+		return map[string]interface{}{
+			"_id":   "abc",
+			"_type": "post",
+			"title": "Old title",
+			"slug": map[string]interface{}{
+				"_type":   "slug",
+				"current": "old-title",
+			},
+			"views": float64(2),
+			"body": []interface{}{
+				map[string]interface{}{"_key": "a", "text": "one"},
+				map[string]interface{}{"_key": "b", "text": "two"},
+			},
+		}
+	}
+
+	cases := []struct {
+		name    string
+		patches []Patch
+		check   func(t *testing.T, doc map[string]interface{})
+	}{
+		{
+			name:    "set nested field",
+			patches: []Patch{Set("slug.current", "new-title"), Set("title", "New title")},
+			check: func(t *testing.T, doc map[string]interface{}) {
+				assert.Equal(t, "new-title", doc["slug"].(map[string]interface{})["current"])
+				assert.Equal(t, "New title", doc["title"])
+			},
+		},
+		{
+			name:    "set if missing",
+			patches: []Patch{SetIfMissing("title", "Ignored"), SetIfMissing("syndication", []string{})},
+			check: func(t *testing.T, doc map[string]interface{}) {
+				assert.Equal(t, "Old title", doc["title"])
+				assert.Equal(t, []interface{}{}, doc["syndication"])
+			},
+		},
+		{
+			name:    "unset by key",
+			patches: []Patch{Unset(`body[_key=="a"]`, "title")},
+			check: func(t *testing.T, doc map[string]interface{}) {
+				assert.NotContains(t, doc, "title")
+				assert.Len(t, doc["body"], 1)
+				assert.Equal(t, "b", doc["body"].([]interface{})[0].(map[string]interface{})["_key"])
+			},
+		},
+		{
+			name:    "insert after last item",
+			patches: []Patch{InsertAfter("body[-1]", map[string]interface{}{"_key": "c"})},
+			check: func(t *testing.T, doc map[string]interface{}) {
+				body := doc["body"].([]interface{})
+				assert.Len(t, body, 3)
+				assert.Equal(t, "c", body[2].(map[string]interface{})["_key"])
+			},
+		},
+		{
+			name:    "insert before keyed item",
+			patches: []Patch{InsertBefore(`body[_key=="b"]`, map[string]interface{}{"_key": "c"})},
+			check: func(t *testing.T, doc map[string]interface{}) {
+				body := doc["body"].([]interface{})
+				assert.Equal(t, "c", body[1].(map[string]interface{})["_key"])
+			},
+		},
+		{
+			name:    "replace item",
+			patches: []Patch{Replace("body[0]", map[string]interface{}{"_key": "c"})},
+			check: func(t *testing.T, doc map[string]interface{}) {
+				body := doc["body"].([]interface{})
+				assert.Len(t, body, 2)
+				assert.Equal(t, "c", body[0].(map[string]interface{})["_key"])
+			},
+		},
+		{
+			name:    "inc and dec",
+			patches: []Patch{Inc("views", 3), Dec("missing", 1)},
+			check: func(t *testing.T, doc map[string]interface{}) {
+				assert.Equal(t, float64(5), doc["views"])
+				assert.NotContains(t, doc, "missing")
+			},
+		},
+		{
+			name:    "unset and inc missing paths",
+			patches: []Patch{Unset("missing.field", "gone[0]", "slug.missing.field"), Inc("counts.views", 1)},
+			check: func(t *testing.T, doc map[string]interface{}) {
+				assert.Equal(t, newDoc(), doc)
+			},
+		},
+		{
+			name: "overlapping sets",
+			patches: []Patch{
+				Set("slug.current", "new-title"),
+				Set("slug", map[string]interface{}{"_type": "slug", "current": "replaced"}),
+			},
+			check: func(t *testing.T, doc map[string]interface{}) {
+				// paths are applied in sorted order, so the nested field is set last
+				assert.Equal(t, "new-title", doc["slug"].(map[string]interface{})["current"])
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := &Description{ID: "abc"}
+			for _, patcher := range c.patches {
+				patcher.Apply(p)
+			}
+
+			doc := newDoc()
+			assert.NoError(t, p.ApplyTo(doc))
+			c.check(t, doc)
+		})
+	}
+}
+
+func TestApplyToInsertIntoEmptyArray(t *testing.T) {
+	p := &Description{}
+	SetIfMissing("syndication", make([]string, 0)).Apply(p)
+	InsertAfter("syndication[-1]", "https://example.com").Apply(p)
+
+	doc := map[string]interface{}{}
+	assert.NoError(t, p.ApplyTo(doc))
+	assert.Equal(t, []interface{}{"https://example.com"}, doc["syndication"])
+}
@@ -106,3 +106,28 @@ func DiffMatchPatch(key string, patch string) Patch {
 		p.DiffMatchPatch[key] = patch
 	})
 }
+
+// Split breaks a list of patches into groups that can each be applied as a single patch, since a
+// patch can only include one insert. A new group is started whenever a patch inserts items and the
+// current group already has an insert, so the inserts are applied in the order they were given.
+func Split(patches ...Patch) [][]Patch {
+	var groups [][]Patch
+	var current []Patch
+	var inserting bool
+	for _, patcher := range patches {
+		var p Description
+		patcher.Apply(&p)
+		if p.Insert != nil {
+			if inserting {
+				groups = append(groups, current)
+				current = nil
+			}
+			inserting = true
+		}
+		current = append(current, patcher)
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
@@ -0,0 +1,55 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	cases := []struct {
+		name    string
+		patches []Patch
+		sizes   []int
+	}{
+		{
+			name: "no patches",
+		},
+		{
+			name:    "no inserts",
+			patches: []Patch{Set("title", "a"), Unset("summary"), Inc("views", 1)},
+			sizes:   []int{3},
+		},
+		{
+			name: "one insert",
+			patches: []Patch{
+				SetIfMissing("syndication", []string{}),
+				InsertAfter("syndication[-1]", "https://example.com"),
+			},
+			sizes: []int{2},
+		},
+		{
+			name: "several inserts",
+			patches: []Patch{
+				Set(`body[_key=="a"]`, "one"),
+				Unset(`body[_key=="b"]`),
+				InsertAfter(`body[_key=="a"]`, "two"),
+				InsertBefore(`body[_key=="c"]`, "three"),
+				SetIfMissing("syndication", []string{}),
+				InsertAfter("syndication[-1]", "https://example.com"),
+			},
+			sizes: []int{3, 2, 1},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			groups := Split(c.patches...)
+			var sizes []int
+			for _, g := range groups {
+				sizes = append(sizes, len(g))
+			}
+			assert.Equal(t, c.sizes, sizes)
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// GenerateGo generates Go types for every document and object type in the schema, with a
+// constant for each type's _type value. The output is formatted Go source for the given package.
+func (s *Schema) GenerateGo(pkg string) ([]byte, error) {
+	g := &goGenerator{
+		schema:  s,
+		imports: make(map[string]bool),
+	}
+
+	for _, t := range s.Types {
+		g.genType(t)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by github.com/mjm/mpsanity/schema. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+
+	if len(g.imports) > 0 {
+		var std, other []string
+		for imp := range g.imports {
+			if strings.Contains(strings.Split(imp, "/")[0], ".") {
+				other = append(other, imp)
+			} else {
+				std = append(std, imp)
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(other)
+
+		out.WriteString("import (\n")
+		for _, imp := range std {
+			fmt.Fprintf(&out, "%q\n", imp)
+		}
+		if len(std) > 0 && len(other) > 0 {
+			out.WriteString("\n")
+		}
+		for _, imp := range other {
+			fmt.Fprintf(&out, "%q\n", imp)
+		}
+		out.WriteString(")\n\n")
+	}
+
+	out.WriteString("const (\n")
+	for _, t := range s.Types {
+		fmt.Fprintf(&out, "Type%s = %q\n", goName(t.Name), t.Name)
+	}
+	out.WriteString(")\n")
+
+	out.Write(g.body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+type goGenerator struct {
+	schema  *Schema
+	imports map[string]bool
+	body    bytes.Buffer
+}
+
+func (g *goGenerator) genType(t *Type) {
+	name := goName(t.Name)
+
+	var article string
+	switch t.Type {
+	case TypeDocument:
+		article = "document"
+	case TypeImage:
+		article = "image"
+	default:
+		article = "object"
+	}
+	fmt.Fprintf(&g.body, "\n// %s is the %q %s type.\n", name, t.Name, article)
+
+	g.genStruct(name, t.Type, t.Fields)
+}
+
+func (g *goGenerator) genStruct(name string, kind string, fields []*Field) {
+	// nested types are generated after this one is finished
+	var nested []func()
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "type %s struct {\n", name)
+	var used []string
+	switch kind {
+	case TypeDocument:
+		s.WriteString("ID string `json:\"_id,omitempty\"`\n")
+		s.WriteString("Type string `json:\"_type\"`\n")
+		used = []string{"ID", "Type"}
+	case TypeImage, "file":
+		embedded := "Image"
+		if kind == "file" {
+			embedded = "File"
+		}
+		g.imports["github.com/mjm/mpsanity"] = true
+		s.WriteString("Key string `json:\"_key,omitempty\"`\n")
+		s.WriteString("mpsanity." + embedded + "\n")
+		used = []string{"Key", embedded}
+	default:
+		s.WriteString("Type string `json:\"_type,omitempty\"`\n")
+		s.WriteString("Key string `json:\"_key,omitempty\"`\n")
+		used = []string{"Type", "Key"}
+	}
+
+	for _, f := range fields {
+		typeName, gen := g.goType(name+goName(f.Name), f.Name, f)
+		if gen != nil {
+			nested = append(nested, gen)
+		}
+
+		// fields like "type" or "key" would collide with the built-in fields, so they get a suffix
+		fieldName := goName(f.Name)
+		for contains(used, fieldName) {
+			fieldName += "Field"
+		}
+		used = append(used, fieldName)
+
+		tag := f.Name
+		if !f.Required {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&s, "%s %s `json:%q`\n", fieldName, typeName, tag)
+	}
+	s.WriteString("}\n")
+
+	g.body.WriteString(s.String())
+
+	for _, gen := range nested {
+		gen()
+	}
+}
+
+// goType returns the Go type for a field. If the field needs its own struct type, it is named
+// nestedName and a function to generate it is returned.
+func (g *goGenerator) goType(nestedName string, fieldName string, f *Field) (string, func()) {
+	switch f.Type {
+	case "string", "text", "url", "date", "email":
+		return "string", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "datetime":
+		g.imports["time"] = true
+		// omitempty doesn't leave out a zero time.Time, so optional times are pointers
+		if !f.Required {
+			return "*time.Time", nil
+		}
+		return "time.Time", nil
+	case "slug":
+		g.imports["github.com/mjm/mpsanity"] = true
+		return "mpsanity.Slug", nil
+	case "reference":
+		g.imports["github.com/mjm/mpsanity"] = true
+		return "mpsanity.Reference", nil
+	case "file":
+		g.imports["github.com/mjm/mpsanity"] = true
+		if len(f.Fields) == 0 {
+			return "*mpsanity.File", nil
+		}
+		return "*" + nestedName, func() {
+			fmt.Fprintf(&g.body, "\n// %s is the file type of the %s field.\n", nestedName, fieldName)
+			g.genStruct(nestedName, "file", f.Fields)
+		}
+	case "block":
+		g.imports["github.com/mjm/mpsanity/block"] = true
+		return "block.Block", nil
+	case "image":
+		g.imports["github.com/mjm/mpsanity"] = true
+		if len(f.Fields) == 0 {
+			return "*mpsanity.Image", nil
+		}
+		return "*" + nestedName, func() {
+			fmt.Fprintf(&g.body, "\n// %s is the image type of the %s field.\n", nestedName, fieldName)
+			g.genStruct(nestedName, TypeImage, f.Fields)
+		}
+	case "object":
+		return "*" + nestedName, func() {
+			fmt.Fprintf(&g.body, "\n// %s is the object type of the %s field.\n", nestedName, fieldName)
+			g.genStruct(nestedName, TypeObject, f.Fields)
+		}
+	case "array":
+		for _, m := range f.Of {
+			if m.Type == "block" {
+				g.imports["github.com/mjm/mpsanity/block"] = true
+				return "[]block.Block", nil
+			}
+		}
+		if len(f.Of) != 1 {
+			return "[]map[string]interface{}", nil
+		}
+
+		typeName, gen := g.goType(nestedName+"Item", fieldName, f.Of[0])
+		return "[]" + strings.TrimPrefix(typeName, "*"), gen
+	default:
+		t := g.schema.Lookup(f.Type)
+		if t == nil {
+			return "interface{}", nil
+		}
+		return "*" + goName(t.Name), nil
+	}
+}
+
+var commonInitialisms = map[string]bool{
+	"API": true, "CSS": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"JSON": true, "RSS": true, "SEO": true, "SQL": true, "URI": true, "URL": true, "XML": true,
+}
+
+// goName converts a Sanity field or type name to an exported Go identifier.
+func goName(name string) string {
+	var words []string
+	var cur []rune
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if len(cur) > 0 {
+				words = append(words, string(cur))
+			}
+			cur = nil
+		case unicode.IsUpper(r) && len(cur) > 0 && !unicode.IsUpper(cur[len(cur)-1]):
+			words = append(words, string(cur))
+			cur = []rune{r}
+		default:
+			cur = append(cur, r)
+		}
+	}
+	if len(cur) > 0 {
+		words = append(words, string(cur))
+	}
+
+	var s strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); commonInitialisms[upper] {
+			s.WriteString(upper)
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		s.WriteString(string(r))
+	}
+
+	if s.Len() == 0 || unicode.IsDigit([]rune(s.String())[0]) {
+		return "X" + s.String()
+	}
+	return s.String()
+}
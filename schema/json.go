@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"encoding/json"
+)
+
+// ParseJSON reads a schema from JSON. The JSON uses the same shape as Sanity Studio schema type
+// definitions: either an array of types, or an object with a "types" array. Validation
+// functions can't be represented in JSON, so a field can set "required", "min" and "max"
+// instead.
+func ParseJSON(data []byte) (*Schema, error) {
+	var types []jsonField
+	if err := json.Unmarshal(data, &types); err != nil {
+		var wrapper struct {
+			Types []jsonField `json:"types"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, err
+		}
+		types = wrapper.Types
+	}
+
+	s := &Schema{}
+	for _, t := range types {
+		s.Types = append(s.Types, &Type{
+			Name:   t.Name,
+			Title:  t.Title,
+			Type:   t.Type,
+			Fields: toFields(t.Fields),
+		})
+	}
+	return s, nil
+}
+
+type jsonField struct {
+	Name     string      `json:"name"`
+	Title    string      `json:"title"`
+	Type     string      `json:"type"`
+	Required bool        `json:"required"`
+	Min      *float64    `json:"min"`
+	Max      *float64    `json:"max"`
+	Of       []jsonField `json:"of"`
+	To       jsonTo      `json:"to"`
+	Fields   []jsonField `json:"fields"`
+	Styles   []jsonValue `json:"styles"`
+	Lists    []jsonValue `json:"lists"`
+	Marks    *jsonMarks  `json:"marks"`
+}
+
+type jsonMarks struct {
+	Decorators  []jsonValue `json:"decorators"`
+	Annotations []jsonField `json:"annotations"`
+}
+
+type jsonValue struct {
+	Value string `json:"value"`
+}
+
+// jsonTo handles reference targets, which can be a single type or an array of them.
+type jsonTo []string
+
+func (to *jsonTo) UnmarshalJSON(data []byte) error {
+	type target struct {
+		Type string `json:"type"`
+	}
+
+	var targets []target
+	if err := json.Unmarshal(data, &targets); err != nil {
+		var single target
+		if err := json.Unmarshal(data, &single); err != nil {
+			return err
+		}
+		targets = []target{single}
+	}
+
+	for _, t := range targets {
+		*to = append(*to, t.Type)
+	}
+	return nil
+}
+
+func toFields(jfs []jsonField) []*Field {
+	var fields []*Field
+	for _, jf := range jfs {
+		fields = append(fields, jf.toField())
+	}
+	return fields
+}
+
+func (jf jsonField) toField() *Field {
+	f := &Field{
+		Name:     jf.Name,
+		Title:    jf.Title,
+		Type:     jf.Type,
+		Required: jf.Required,
+		Min:      jf.Min,
+		Max:      jf.Max,
+		Of:       toFields(jf.Of),
+		To:       jf.To,
+		Fields:   toFields(jf.Fields),
+		Styles:   values(jf.Styles),
+		Lists:    values(jf.Lists),
+	}
+	if jf.Marks != nil {
+		f.Decorators = values(jf.Marks.Decorators)
+		f.Annotations = toFields(jf.Marks.Annotations)
+	}
+	return f
+}
+
+func values(vs []jsonValue) []string {
+	var out []string
+	for _, v := range vs {
+		out = append(out, v.Value)
+	}
+	return out
+}
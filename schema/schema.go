@@ -0,0 +1,268 @@
+package schema
+
+// Schema is a set of document and object types, mirroring the schema definitions used by Sanity
+// Studio.
+type Schema struct {
+	Types []*Type
+}
+
+func New(types ...*Type) *Schema {
+	return &Schema{Types: types}
+}
+
+// Lookup finds the type with the given name, or returns nil if there isn't one.
+func (s *Schema) Lookup(name string) *Type {
+	for _, t := range s.Types {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Type is a named document or object type.
+type Type struct {
+	Name   string
+	Title  string
+	Type   string
+	Fields []*Field
+}
+
+const (
+	TypeDocument = "document"
+	TypeObject   = "object"
+	TypeImage    = "image"
+)
+
+func Document(name string, title string, fields ...*Field) *Type {
+	return &Type{Name: name, Title: title, Type: TypeDocument, Fields: fields}
+}
+
+func Object(name string, title string, fields ...*Field) *Type {
+	return &Type{Name: name, Title: title, Type: TypeObject, Fields: fields}
+}
+
+// ImageObject is a named image type with extra fields, like alt text or a caption.
+func ImageObject(name string, title string, fields ...*Field) *Type {
+	return &Type{Name: name, Title: title, Type: TypeImage, Fields: fields}
+}
+
+// Field is a field of an object type, or a member type of an array field. Array members have no
+// name.
+type Field struct {
+	Name     string
+	Title    string
+	Type     string
+	Required bool
+	Min      *float64
+	Max      *float64
+
+	// Of lists the allowed member types of an array field.
+	Of []*Field
+	// To lists the document types a reference field can point to.
+	To []string
+	// Fields are the fields of an inline object or image field.
+	Fields []*Field
+
+	// Styles, Lists, Decorators and Annotations restrict what is allowed in a block. An empty
+	// list allows the Studio defaults.
+	Styles      []string
+	Lists       []string
+	Decorators  []string
+	Annotations []*Field
+}
+
+type FieldOption interface {
+	Apply(f *Field)
+}
+
+type fieldOptionFn func(f *Field)
+
+func (fn fieldOptionFn) Apply(f *Field) {
+	fn(f)
+}
+
+func Required() FieldOption {
+	return fieldOptionFn(func(f *Field) {
+		f.Required = true
+	})
+}
+
+// Min sets the minimum length of a string or array, or the minimum value of a number.
+func Min(n float64) FieldOption {
+	return fieldOptionFn(func(f *Field) {
+		f.Min = &n
+	})
+}
+
+// Max sets the maximum length of a string or array, or the maximum value of a number.
+func Max(n float64) FieldOption {
+	return fieldOptionFn(func(f *Field) {
+		f.Max = &n
+	})
+}
+
+func Title(title string) FieldOption {
+	return fieldOptionFn(func(f *Field) {
+		f.Title = title
+	})
+}
+
+func Of(members ...*Field) FieldOption {
+	return fieldOptionFn(func(f *Field) {
+		f.Of = append(f.Of, members...)
+	})
+}
+
+func To(types ...string) FieldOption {
+	return fieldOptionFn(func(f *Field) {
+		f.To = append(f.To, types...)
+	})
+}
+
+func Fields(fields ...*Field) FieldOption {
+	return fieldOptionFn(func(f *Field) {
+		f.Fields = append(f.Fields, fields...)
+	})
+}
+
+func Styles(styles ...string) FieldOption {
+	return fieldOptionFn(func(f *Field) {
+		f.Styles = append(f.Styles, styles...)
+	})
+}
+
+func Lists(lists ...string) FieldOption {
+	return fieldOptionFn(func(f *Field) {
+		f.Lists = append(f.Lists, lists...)
+	})
+}
+
+func Decorators(decorators ...string) FieldOption {
+	return fieldOptionFn(func(f *Field) {
+		f.Decorators = append(f.Decorators, decorators...)
+	})
+}
+
+func Annotations(annotations ...*Field) FieldOption {
+	return fieldOptionFn(func(f *Field) {
+		f.Annotations = append(f.Annotations, annotations...)
+	})
+}
+
+func newField(name string, typeName string, opts []FieldOption) *Field {
+	f := &Field{Name: name, Type: typeName}
+	for _, o := range opts {
+		o.Apply(f)
+	}
+	return f
+}
+
+func String(name string, opts ...FieldOption) *Field {
+	return newField(name, "string", opts)
+}
+
+func Text(name string, opts ...FieldOption) *Field {
+	return newField(name, "text", opts)
+}
+
+func Number(name string, opts ...FieldOption) *Field {
+	return newField(name, "number", opts)
+}
+
+func Boolean(name string, opts ...FieldOption) *Field {
+	return newField(name, "boolean", opts)
+}
+
+func Datetime(name string, opts ...FieldOption) *Field {
+	return newField(name, "datetime", opts)
+}
+
+func Slug(name string, opts ...FieldOption) *Field {
+	return newField(name, "slug", opts)
+}
+
+func URL(name string, opts ...FieldOption) *Field {
+	return newField(name, "url", opts)
+}
+
+func Reference(name string, opts ...FieldOption) *Field {
+	return newField(name, "reference", opts)
+}
+
+func Image(name string, opts ...FieldOption) *Field {
+	return newField(name, "image", opts)
+}
+
+func Array(name string, opts ...FieldOption) *Field {
+	return newField(name, "array", opts)
+}
+
+// InlineObject is an anonymous object field whose fields are given with Fields.
+func InlineObject(name string, opts ...FieldOption) *Field {
+	return newField(name, "object", opts)
+}
+
+// Typed is a field whose type is one of the named types in the schema.
+func Typed(name string, typeName string, opts ...FieldOption) *Field {
+	return newField(name, typeName, opts)
+}
+
+// Block is an array member for Portable Text blocks.
+func Block(opts ...FieldOption) *Field {
+	return newField("", "block", opts)
+}
+
+// Member is an array member of the given type.
+func Member(typeName string, opts ...FieldOption) *Field {
+	return newField("", typeName, opts)
+}
+
+var (
+	defaultStyles      = []string{"normal", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote"}
+	defaultLists       = []string{"bullet", "number"}
+	defaultDecorators  = []string{"strong", "em", "code", "underline", "strike-through"}
+	defaultAnnotations = []string{"link"}
+)
+
+func (f *Field) allowedStyles() []string {
+	if len(f.Styles) == 0 {
+		return defaultStyles
+	}
+	return f.Styles
+}
+
+func (f *Field) allowedLists() []string {
+	if len(f.Lists) == 0 {
+		return defaultLists
+	}
+	return f.Lists
+}
+
+func (f *Field) allowedDecorators() []string {
+	if len(f.Decorators) == 0 {
+		return defaultDecorators
+	}
+	return f.Decorators
+}
+
+func (f *Field) allowedAnnotations() []string {
+	if len(f.Annotations) == 0 {
+		return defaultAnnotations
+	}
+
+	var names []string
+	for _, a := range f.Annotations {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+func contains(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
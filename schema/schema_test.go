@@ -0,0 +1,205 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mjm/mpsanity"
+)
+
+var testSchema = New(
+	Document("post", "Post",
+		String("title", Required(), Max(10)),
+		Slug("slug", Required()),
+		Array("body", Of(
+			Block(Styles("normal"), Decorators("em")),
+			Member("mainImage"))),
+		Array("tags", Of(String("")), Max(2))),
+	ImageObject("mainImage", "Image",
+		String("alt", Required())),
+)
+
+func TestValidate(t *testing.T) {
+	err := testSchema.Validate(map[string]interface{}{
+		"_id":   "abc",
+		"_type": "post",
+		"title": "A title that is too long",
+		"body": []interface{}{
+			map[string]interface{}{
+				"_type": "block",
+				"_key":  "b1",
+				"style": "h1",
+				"children": []interface{}{
+					map[string]interface{}{
+						"_type": "span",
+						"text":  "Hi",
+						"marks": []interface{}{"em", "strong", "link1"},
+					},
+				},
+				"markDefs": []interface{}{},
+			},
+			map[string]interface{}{
+				"_type": "mainImage",
+				"asset": map[string]interface{}{"_type": "reference", "_ref": "image-abc"},
+			},
+			map[string]interface{}{
+				"_type": "tweet",
+			},
+		},
+		"tags": []interface{}{"a", "b", "c"},
+	})
+
+	var errs mpsanity.ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, mpsanity.ValidationErrors{
+		{Path: "title", Message: "must be at most 10 characters"},
+		{Path: "slug", Message: "is required"},
+		{Path: `body[_key=="b1"].style`, Message: `style "h1" is not allowed`},
+		{Path: `body[_key=="b1"].children[0].marks`, Message: `mark "strong" is not an allowed decorator or a defined annotation`},
+		{Path: `body[_key=="b1"].children[0].marks`, Message: `mark "link1" is not an allowed decorator or a defined annotation`},
+		{Path: "body[1].alt", Message: "is required"},
+		{Path: "body[2]", Message: `has type "tweet", which is not allowed here`},
+		{Path: "tags", Message: "must have at most 2 items"},
+	}, errs)
+}
+
+func TestValidateValidDocument(t *testing.T) {
+	assert.NoError(t, testSchema.Validate(map[string]interface{}{
+		"_type": "post",
+		"title": "Hello",
+		"slug":  map[string]interface{}{"_type": "slug", "current": "hello"},
+		"tags":  []interface{}{"a"},
+	}))
+}
+
+func TestStudioSource(t *testing.T) {
+	assert.Equal(t, `// Code generated by github.com/mjm/mpsanity/schema. DO NOT EDIT.
+
+export default {
+  name: 'mainImage',
+  title: 'Image',
+  type: 'image',
+  fields: [
+    {
+      name: 'alt',
+      type: 'string',
+      validation: Rule => Rule.required(),
+    },
+  ],
+}
+`, testSchema.Lookup("mainImage").StudioSource())
+
+	files := testSchema.StudioFiles()
+	assert.Contains(t, files, "post.js")
+	assert.Contains(t, string(files["post.js"]), `decorators: [
+              {
+                title: 'Em',
+                value: 'em',
+              },
+            ],`)
+	assert.Contains(t, string(files["schema.js"]), "types: schemaTypes.concat([post, mainImage]),")
+}
+
+func TestGenerateGo(t *testing.T) {
+	s, err := ParseJSON([]byte(`[
+		{"name": "post", "type": "document", "fields": [
+			{"name": "title", "type": "string", "required": true},
+			{"name": "slug", "type": "slug"},
+			{"name": "author", "type": "reference", "to": {"type": "author"}},
+			{"name": "body", "type": "array", "of": [{"type": "block"}, {"type": "mainImage"}]},
+			{"name": "tags", "type": "array", "of": [{"type": "string"}]}
+		]},
+		{"name": "mainImage", "type": "image", "fields": [
+			{"name": "alt", "type": "string"}
+		]}
+	]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"author"}, s.Lookup("post").Fields[2].To)
+
+	src, err := s.GenerateGo("content")
+	assert.NoError(t, err)
+	assert.Equal(t, `// Code generated by github.com/mjm/mpsanity/schema. DO NOT EDIT.
+
+package content
+
+import (
+	"github.com/mjm/mpsanity"
+	"github.com/mjm/mpsanity/block"
+)
+
+const (
+	TypePost      = "post"
+	TypeMainImage = "mainImage"
+)
+
+// Post is the "post" document type.
+type Post struct {
+	ID     string             `+"`json:\"_id,omitempty\"`"+`
+	Type   string             `+"`json:\"_type\"`"+`
+	Title  string             `+"`json:\"title\"`"+`
+	Slug   mpsanity.Slug      `+"`json:\"slug,omitempty\"`"+`
+	Author mpsanity.Reference `+"`json:\"author,omitempty\"`"+`
+	Body   []block.Block      `+"`json:\"body,omitempty\"`"+`
+	Tags   []string           `+"`json:\"tags,omitempty\"`"+`
+}
+
+// MainImage is the "mainImage" image type.
+type MainImage struct {
+	Key string `+"`json:\"_key,omitempty\"`"+`
+	mpsanity.Image
+	Alt string `+"`json:\"alt,omitempty\"`"+`
+}
+`, string(src))
+}
+
+func TestGenerateGoFieldTypes(t *testing.T) {
+	s, err := ParseJSON([]byte(`[
+		{"name": "event", "type": "document", "fields": [
+			{"name": "id", "type": "string"},
+			{"name": "type", "type": "string"},
+			{"name": "startsAt", "type": "datetime", "required": true},
+			{"name": "endsAt", "type": "datetime"},
+			{"name": "attachment", "type": "file"},
+			{"name": "slides", "type": "file", "fields": [
+				{"name": "key", "type": "string"}
+			]}
+		]}
+	]`))
+	assert.NoError(t, err)
+
+	src, err := s.GenerateGo("content")
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), `// Event is the "event" document type.
+type Event struct {
+	ID         string         `+"`json:\"_id,omitempty\"`"+`
+	Type       string         `+"`json:\"_type\"`"+`
+	IDField    string         `+"`json:\"id,omitempty\"`"+`
+	TypeField  string         `+"`json:\"type,omitempty\"`"+`
+	StartsAt   time.Time      `+"`json:\"startsAt\"`"+`
+	EndsAt     *time.Time     `+"`json:\"endsAt,omitempty\"`"+`
+	Attachment *mpsanity.File `+"`json:\"attachment,omitempty\"`"+`
+	Slides     *EventSlides   `+"`json:\"slides,omitempty\"`"+`
+}
+
+// EventSlides is the file type of the slides field.
+type EventSlides struct {
+	Key string `+"`json:\"_key,omitempty\"`"+`
+	mpsanity.File
+	KeyField string `+"`json:\"key,omitempty\"`"+`
+}
+`)
+}
+
+func TestGoName(t *testing.T) {
+	for in, out := range map[string]string{
+		"post":        "Post",
+		"publishedAt": "PublishedAt",
+		"imageUrl":    "ImageURL",
+		"seo_title":   "SEOTitle",
+		"3d-model":    "X3dModel",
+	} {
+		assert.Equal(t, out, goName(in), in)
+	}
+}
@@ -0,0 +1,254 @@
+package schema
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const studioHeader = "// Code generated by github.com/mjm/mpsanity/schema. DO NOT EDIT.\n\n"
+
+// StudioFiles generates the Sanity Studio schema definitions for the schema. There is one file
+// per type, named after the type, and a schema.js that combines them all.
+func (s *Schema) StudioFiles() map[string][]byte {
+	files := make(map[string][]byte)
+
+	var index strings.Builder
+	index.WriteString(studioHeader)
+	index.WriteString("import createSchema from 'part:@sanity/base/schema-creator'\n")
+	index.WriteString("import schemaTypes from 'all:part:@sanity/base/schema-type'\n\n")
+
+	var idents []string
+	for _, t := range s.Types {
+		files[t.Name+".js"] = []byte(t.StudioSource())
+
+		ident := jsIdent(t.Name)
+		idents = append(idents, ident)
+		fmt.Fprintf(&index, "import %s from %s\n", ident, jsString("./"+t.Name))
+	}
+
+	index.WriteString("\nexport default createSchema({\n")
+	index.WriteString("  name: 'default',\n")
+	fmt.Fprintf(&index, "  types: schemaTypes.concat([%s]),\n", strings.Join(idents, ", "))
+	index.WriteString("})\n")
+
+	files["schema.js"] = []byte(index.String())
+	return files
+}
+
+// WriteStudioFiles writes the files from StudioFiles into a directory.
+func (s *Schema) WriteStudioFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	files := s.StudioFiles()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), files[name], 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StudioSource generates the JavaScript module for a single type's Studio schema.
+func (t *Type) StudioSource() string {
+	var w jsWriter
+	w.WriteString(studioHeader)
+	w.WriteString("export default ")
+	w.value(t.studio(), 0)
+	w.WriteString("\n")
+	return w.String()
+}
+
+func (t *Type) studio() jsObject {
+	obj := jsObject{
+		{"name", t.Name},
+	}
+	if t.Title != "" {
+		obj = append(obj, jsProp{"title", t.Title})
+	}
+	obj = append(obj, jsProp{"type", t.Type})
+	obj = append(obj, jsProp{"fields", studioFields(t.Fields)})
+	return obj
+}
+
+func studioFields(fields []*Field) []interface{} {
+	vals := make([]interface{}, 0, len(fields))
+	for _, f := range fields {
+		vals = append(vals, f.studio())
+	}
+	return vals
+}
+
+func (f *Field) studio() jsObject {
+	var obj jsObject
+	if f.Name != "" {
+		obj = append(obj, jsProp{"name", f.Name})
+	}
+	if f.Title != "" {
+		obj = append(obj, jsProp{"title", f.Title})
+	}
+	obj = append(obj, jsProp{"type", f.Type})
+
+	if len(f.Of) > 0 {
+		obj = append(obj, jsProp{"of", studioFields(f.Of)})
+	}
+	if len(f.To) > 0 {
+		var to []interface{}
+		for _, t := range f.To {
+			to = append(to, jsObject{{"type", t}})
+		}
+		obj = append(obj, jsProp{"to", to})
+	}
+	if len(f.Fields) > 0 {
+		obj = append(obj, jsProp{"fields", studioFields(f.Fields)})
+	}
+
+	if f.Type == "block" {
+		if len(f.Styles) > 0 {
+			obj = append(obj, jsProp{"styles", titledValues(f.Styles)})
+		}
+		if len(f.Lists) > 0 {
+			obj = append(obj, jsProp{"lists", titledValues(f.Lists)})
+		}
+		if len(f.Decorators) > 0 || len(f.Annotations) > 0 {
+			var marks jsObject
+			if len(f.Decorators) > 0 {
+				marks = append(marks, jsProp{"decorators", titledValues(f.Decorators)})
+			}
+			if len(f.Annotations) > 0 {
+				marks = append(marks, jsProp{"annotations", studioFields(f.Annotations)})
+			}
+			obj = append(obj, jsProp{"marks", marks})
+		}
+	}
+
+	if rule := f.rule(); rule != "" {
+		obj = append(obj, jsProp{"validation", jsRaw("Rule => Rule" + rule)})
+	}
+	return obj
+}
+
+func (f *Field) rule() string {
+	var rule strings.Builder
+	if f.Required {
+		rule.WriteString(".required()")
+	}
+	if f.Min != nil {
+		fmt.Fprintf(&rule, ".min(%s)", strconv.FormatFloat(*f.Min, 'f', -1, 64))
+	}
+	if f.Max != nil {
+		fmt.Fprintf(&rule, ".max(%s)", strconv.FormatFloat(*f.Max, 'f', -1, 64))
+	}
+	return rule.String()
+}
+
+func titledValues(vals []string) []interface{} {
+	var out []interface{}
+	for _, v := range vals {
+		out = append(out, jsObject{
+			{"title", titleize(v)},
+			{"value", v},
+		})
+	}
+	return out
+}
+
+func titleize(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '-' || r == '_' || r == ' '
+	})
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+func jsIdent(name string) string {
+	var s strings.Builder
+	for i, r := range name {
+		if unicode.IsLetter(r) || r == '_' || r == '$' || (i > 0 && unicode.IsDigit(r)) {
+			s.WriteRune(r)
+		} else {
+			s.WriteRune('_')
+		}
+	}
+	return s.String()
+}
+
+func jsString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`)
+	return "'" + r.Replace(s) + "'"
+}
+
+type jsProp struct {
+	Key   string
+	Value interface{}
+}
+
+type jsObject []jsProp
+
+type jsRaw string
+
+type jsWriter struct {
+	strings.Builder
+}
+
+func (w *jsWriter) indent(depth int) {
+	w.WriteString(strings.Repeat("  ", depth))
+}
+
+func (w *jsWriter) value(v interface{}, depth int) {
+	switch val := v.(type) {
+	case string:
+		w.WriteString(jsString(val))
+	case jsRaw:
+		w.WriteString(string(val))
+	case bool:
+		w.WriteString(strconv.FormatBool(val))
+	case jsObject:
+		if len(val) == 0 {
+			w.WriteString("{}")
+			return
+		}
+		w.WriteString("{\n")
+		for _, p := range val {
+			w.indent(depth + 1)
+			w.WriteString(p.Key)
+			w.WriteString(": ")
+			w.value(p.Value, depth+1)
+			w.WriteString(",\n")
+		}
+		w.indent(depth)
+		w.WriteString("}")
+	case []interface{}:
+		if len(val) == 0 {
+			w.WriteString("[]")
+			return
+		}
+		w.WriteString("[\n")
+		for _, item := range val {
+			w.indent(depth + 1)
+			w.value(item, depth+1)
+			w.WriteString(",\n")
+		}
+		w.indent(depth)
+		w.WriteString("]")
+	default:
+		fmt.Fprintf(w, "%v", val)
+	}
+}
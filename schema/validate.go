@@ -0,0 +1,347 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mjm/mpsanity"
+)
+
+// Validate checks a document, in its generic JSON form, against the type named by its _type.
+// Any problems are returned as mpsanity.ValidationErrors.
+func (s *Schema) Validate(doc map[string]interface{}) error {
+	v := &validator{schema: s}
+
+	docType, _ := doc["_type"].(string)
+	if t := s.Lookup(docType); t == nil {
+		v.errorf("_type", "unknown type %q", docType)
+	} else {
+		v.fields("", t.Fields, doc)
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// Register adds a validator to the client for each document type in the schema, so that
+// transactions are checked against the schema before they are committed.
+func (s *Schema) Register(c *mpsanity.Client) {
+	for _, t := range s.Types {
+		if t.Type == TypeDocument {
+			c.AddValidator(t.Name, mpsanity.ValidatorFunc(s.Validate))
+		}
+	}
+}
+
+type validator struct {
+	schema *Schema
+	errs   mpsanity.ValidationErrors
+}
+
+func (v *validator) errorf(path string, format string, args ...interface{}) {
+	v.errs = append(v.errs, &mpsanity.ValidationError{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func fieldPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func itemPath(path string, i int, item interface{}) string {
+	if m, ok := item.(map[string]interface{}); ok {
+		if key, ok := m["_key"].(string); ok && key != "" {
+			return fmt.Sprintf("%s[_key==%q]", path, key)
+		}
+	}
+	return fmt.Sprintf("%s[%d]", path, i)
+}
+
+func (v *validator) fields(path string, fields []*Field, obj map[string]interface{}) {
+	for _, f := range fields {
+		val, ok := obj[f.Name]
+		v.value(fieldPath(path, f.Name), f, val, ok && val != nil)
+	}
+}
+
+func (v *validator) value(path string, f *Field, val interface{}, present bool) {
+	if !present {
+		if f.Required {
+			v.errorf(path, "is required")
+		}
+		return
+	}
+
+	switch f.Type {
+	case "string", "text":
+		s, ok := val.(string)
+		if !ok {
+			v.errorf(path, "must be a string")
+			return
+		}
+		v.length(path, f, s)
+	case "url":
+		s, ok := val.(string)
+		if !ok {
+			v.errorf(path, "must be a string")
+			return
+		}
+		if u, err := url.Parse(s); err != nil || u.Scheme == "" || u.Host == "" {
+			v.errorf(path, "must be an absolute URL")
+			return
+		}
+		v.length(path, f, s)
+	case "number":
+		n, ok := toFloat(val)
+		if !ok {
+			v.errorf(path, "must be a number")
+			return
+		}
+		if f.Min != nil && n < *f.Min {
+			v.errorf(path, "must be at least %v", *f.Min)
+		}
+		if f.Max != nil && n > *f.Max {
+			v.errorf(path, "must be at most %v", *f.Max)
+		}
+	case "boolean":
+		if _, ok := val.(bool); !ok {
+			v.errorf(path, "must be a boolean")
+		}
+	case "datetime":
+		s, ok := val.(string)
+		if !ok {
+			v.errorf(path, "must be a string")
+			return
+		}
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			v.errorf(path, "must be an RFC 3339 timestamp")
+		}
+	case "slug":
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			v.errorf(path, "must be a slug")
+			return
+		}
+		current, _ := m["current"].(string)
+		if current == "" {
+			if f.Required {
+				v.errorf(fieldPath(path, "current"), "is required")
+			}
+			return
+		}
+		v.length(fieldPath(path, "current"), f, current)
+	case "reference":
+		v.reference(path, val)
+	case "image":
+		v.image(path, f.Fields, val)
+	case "array":
+		v.array(path, f, val)
+	case "object":
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			v.errorf(path, "must be an object")
+			return
+		}
+		v.fields(path, f.Fields, m)
+	case "block":
+		v.block(path, f, val)
+	default:
+		t := v.schema.Lookup(f.Type)
+		if t == nil {
+			v.errorf(path, "has unknown type %q", f.Type)
+			return
+		}
+		if t.Type == TypeImage {
+			v.image(path, t.Fields, val)
+			return
+		}
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			v.errorf(path, "must be an object")
+			return
+		}
+		v.fields(path, t.Fields, m)
+	}
+}
+
+func (v *validator) length(path string, f *Field, s string) {
+	n := float64(utf8.RuneCountInString(s))
+	if f.Required && n == 0 {
+		v.errorf(path, "is required")
+		return
+	}
+	if f.Min != nil && n < *f.Min {
+		v.errorf(path, "must be at least %v characters", *f.Min)
+	}
+	if f.Max != nil && n > *f.Max {
+		v.errorf(path, "must be at most %v characters", *f.Max)
+	}
+}
+
+func (v *validator) reference(path string, val interface{}) {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		v.errorf(path, "must be a reference")
+		return
+	}
+	if ref, _ := m["_ref"].(string); ref == "" {
+		v.errorf(fieldPath(path, "_ref"), "is required")
+	}
+}
+
+func (v *validator) image(path string, fields []*Field, val interface{}) {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		v.errorf(path, "must be an image")
+		return
+	}
+	if asset, ok := m["asset"]; !ok || asset == nil {
+		v.errorf(fieldPath(path, "asset"), "is required")
+	} else {
+		v.reference(fieldPath(path, "asset"), asset)
+	}
+	v.fields(path, fields, m)
+}
+
+func (v *validator) array(path string, f *Field, val interface{}) {
+	items, ok := val.([]interface{})
+	if !ok {
+		v.errorf(path, "must be an array")
+		return
+	}
+
+	n := float64(len(items))
+	if f.Required && n == 0 {
+		v.errorf(path, "is required")
+	}
+	if f.Min != nil && n < *f.Min {
+		v.errorf(path, "must have at least %v items", *f.Min)
+	}
+	if f.Max != nil && n > *f.Max {
+		v.errorf(path, "must have at most %v items", *f.Max)
+	}
+
+	for i, item := range items {
+		p := itemPath(path, i, item)
+		if len(f.Of) == 0 {
+			continue
+		}
+
+		typeName := memberType(item)
+		var member *Field
+		for _, m := range f.Of {
+			// inline objects in an array are stored with their member name as the type
+			if m.Type == typeName || (m.Type == "object" && m.Name == typeName) ||
+				(typeName == "string" && isStringType(m.Type)) {
+				member = m
+				break
+			}
+		}
+		if member == nil {
+			v.errorf(p, "has type %q, which is not allowed here", typeName)
+			continue
+		}
+		v.value(p, member, item, item != nil)
+	}
+}
+
+func memberType(item interface{}) string {
+	switch i := item.(type) {
+	case map[string]interface{}:
+		t, _ := i["_type"].(string)
+		return t
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	default:
+		return ""
+	}
+}
+
+func (v *validator) block(path string, f *Field, val interface{}) {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		v.errorf(path, "must be a block")
+		return
+	}
+
+	if style, ok := m["style"].(string); ok && !contains(f.allowedStyles(), style) {
+		v.errorf(fieldPath(path, "style"), "style %q is not allowed", style)
+	}
+	if listItem, ok := m["listItem"].(string); ok && !contains(f.allowedLists(), listItem) {
+		v.errorf(fieldPath(path, "listItem"), "list type %q is not allowed", listItem)
+	}
+
+	markKeys := make(map[string]bool)
+	markDefs, _ := m["markDefs"].([]interface{})
+	for i, md := range markDefs {
+		p := itemPath(fieldPath(path, "markDefs"), i, md)
+		mdm, ok := md.(map[string]interface{})
+		if !ok {
+			v.errorf(p, "must be an object")
+			continue
+		}
+		if t, _ := mdm["_type"].(string); !contains(f.allowedAnnotations(), t) {
+			v.errorf(p, "annotation %q is not allowed", t)
+		}
+		if key, _ := mdm["_key"].(string); key != "" {
+			markKeys[key] = true
+		} else {
+			v.errorf(fieldPath(p, "_key"), "is required")
+		}
+	}
+
+	children, _ := m["children"].([]interface{})
+	for i, child := range children {
+		p := itemPath(fieldPath(path, "children"), i, child)
+		cm, ok := child.(map[string]interface{})
+		if !ok {
+			v.errorf(p, "must be an object")
+			continue
+		}
+		if t, _ := cm["_type"].(string); t != "span" {
+			continue
+		}
+		marks, _ := cm["marks"].([]interface{})
+		for _, mark := range marks {
+			s, _ := mark.(string)
+			if markKeys[s] || contains(f.allowedDecorators(), s) {
+				continue
+			}
+			v.errorf(fieldPath(p, "marks"), "mark %q is not an allowed decorator or a defined annotation", s)
+		}
+	}
+}
+
+func isStringType(typeName string) bool {
+	switch typeName {
+	case "string", "text", "url", "datetime":
+		return true
+	default:
+		return false
+	}
+}
+
+func toFloat(val interface{}) (float64, bool) {
+	switch n := val.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -13,4 +13,8 @@ var (
 	docIDKey         = key.New("sanity.doc_id").String
 	queryKey         = key.New("sanity.query").String
 	mutationCountKey = key.New("sanity.mutation_count").Int
+
+	revisionKey         = key.New("sanity.revision").String
+	historyTimeKey      = key.New("sanity.history_time").String
+	transactionCountKey = key.New("sanity.transaction_count").Int
 )
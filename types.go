@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+
+	"github.com/mjm/mpsanity/block"
 )
 
 var (
 	ErrNotSlug      = errors.New("value is not a slug")
-	ErrNotReference = errors.New("value is not a reference")
+	ErrNotReference = block.ErrNotReference
 )
 
 type Slug string
@@ -40,31 +42,33 @@ type internalSlug struct {
 	Current string `json:"current"`
 }
 
-type Reference string
+// Reference is the ID of another document or asset, stored in Sanity's reference format.
+type Reference = block.Reference
 
-func (r Reference) MarshalJSON() ([]byte, error) {
-	ref := internalReference{
-		Type: "reference",
-		Ref:  string(r),
-	}
-	return json.Marshal(ref)
+// Image is the value of an image field. Named image types with extra fields can embed it.
+type Image struct {
+	Type    string    `json:"_type,omitempty"`
+	Asset   Reference `json:"asset"`
+	Crop    *Crop     `json:"crop,omitempty"`
+	Hotspot *Hotspot  `json:"hotspot,omitempty"`
 }
 
-func (r *Reference) UnmarshalJSON(b []byte) error {
-	var ref internalReference
-	if err := json.Unmarshal(b, &ref); err != nil {
-		return err
-	}
-
-	if ref.Type != "reference" {
-		return fmt.Errorf("%s %w", ref.Type, ErrNotReference)
-	}
+// File is the value of a file field.
+type File struct {
+	Type  string    `json:"_type,omitempty"`
+	Asset Reference `json:"asset"`
+}
 
-	*r = Reference(ref.Ref)
-	return nil
+type Crop struct {
+	Top    float64 `json:"top"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+	Right  float64 `json:"right"`
 }
 
-type internalReference struct {
-	Type string `json:"_type"`
-	Ref  string `json:"_ref"`
+type Hotspot struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
 }
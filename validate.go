@@ -0,0 +1,236 @@
+package mpsanity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mjm/mpsanity/patch"
+)
+
+// Validator checks a document before it is sent to Sanity. The document is provided in its
+// generic JSON form. Returning a *ValidationError or ValidationErrors lets the validator point at
+// the specific field that is invalid; any other error is reported against the whole document.
+type Validator interface {
+	Validate(doc map[string]interface{}) error
+}
+
+type ValidatorFunc func(doc map[string]interface{}) error
+
+func (fn ValidatorFunc) Validate(doc map[string]interface{}) error {
+	return fn(doc)
+}
+
+// ValidationError describes a single problem with a document.
+type ValidationError struct {
+	DocumentID   string
+	DocumentType string
+	Path         string
+	Message      string
+	// Err is the error the validator returned, if it was different from this one.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	var s strings.Builder
+	if e.DocumentType != "" {
+		s.WriteString(e.DocumentType)
+	}
+	if e.DocumentID != "" {
+		if s.Len() > 0 {
+			s.WriteString(" ")
+		}
+		fmt.Fprintf(&s, "%q", e.DocumentID)
+	}
+	if e.Path != "" {
+		if s.Len() > 0 {
+			s.WriteString(" ")
+		}
+		s.WriteString(e.Path)
+	}
+	if s.Len() > 0 {
+		s.WriteString(": ")
+	}
+	s.WriteString(e.Message)
+	return s.String()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors collects every problem found while validating a transaction.
+type ValidationErrors []*ValidationError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return fmt.Sprintf("validation failed: %s", strings.Join(msgs, "; "))
+}
+
+// AddValidator registers a validator to run for every document of the given type that is
+// created, replaced or patched in a transaction.
+func (c *Client) AddValidator(docType string, v Validator) {
+	if c.validators == nil {
+		c.validators = make(map[string][]Validator)
+	}
+	c.validators[docType] = append(c.validators[docType], v)
+}
+
+func (c *Client) validateDoc(doc map[string]interface{}) ValidationErrors {
+	id, _ := doc["_id"].(string)
+	docType, _ := doc["_type"].(string)
+
+	var errs ValidationErrors
+	for _, v := range c.validators[docType] {
+		err := v.Validate(doc)
+		if err == nil {
+			continue
+		}
+
+		var found ValidationErrors
+		var ve *ValidationError
+		var ves ValidationErrors
+		if errors.As(err, &ves) {
+			found = ves
+		} else if errors.As(err, &ve) {
+			found = ValidationErrors{ve}
+		} else {
+			errs = append(errs, &ValidationError{
+				DocumentID:   id,
+				DocumentType: docType,
+				Message:      err.Error(),
+				Err:          err,
+			})
+			continue
+		}
+
+		// the validator's errors are its own, so fill in the document on a copy
+		for _, e := range found {
+			wrapped := *e
+			wrapped.Err = e
+			if wrapped.DocumentID == "" {
+				wrapped.DocumentID = id
+			}
+			if wrapped.DocumentType == "" {
+				wrapped.DocumentType = docType
+			}
+			errs = append(errs, &wrapped)
+		}
+	}
+	return errs
+}
+
+// validate runs the client's validators against the documents the transaction would write.
+// Patched documents are fetched and the patches are applied locally before validating them.
+func (t *Txn) validate(ctx context.Context) error {
+	c := t.client
+	if len(c.validators) == 0 {
+		return nil
+	}
+
+	// keep track of documents already seen in this transaction so later patches build on them
+	docs := make(map[string]map[string]interface{})
+
+	var errs ValidationErrors
+	for _, m := range t.mutations {
+		var toValidate []map[string]interface{}
+
+		switch {
+		case m.Create != nil || m.CreateOrReplace != nil || m.CreateIfNotExists != nil:
+			var v interface{}
+			switch {
+			case m.Create != nil:
+				v = m.Create
+			case m.CreateOrReplace != nil:
+				v = m.CreateOrReplace
+			default:
+				v = m.CreateIfNotExists
+			}
+
+			doc, err := toDocMap(v)
+			if err != nil {
+				return err
+			}
+			if id, ok := doc["_id"].(string); ok && id != "" {
+				docs[id] = doc
+			}
+			toValidate = append(toValidate, doc)
+		case m.Patch != nil:
+			patched, err := t.patchedDocs(ctx, m.Patch, docs)
+			if err != nil {
+				return err
+			}
+			toValidate = append(toValidate, patched...)
+		}
+
+		for _, doc := range toValidate {
+			errs = append(errs, c.validateDoc(doc)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (t *Txn) patchedDocs(ctx context.Context, p *patch.Description, docs map[string]map[string]interface{}) ([]map[string]interface{}, error) {
+	c := t.client
+
+	var targets []map[string]interface{}
+	if p.ID != "" {
+		doc, ok := docs[p.ID]
+		if !ok {
+			doc = make(map[string]interface{})
+			if err := c.Doc(ctx, p.ID, &doc); err != nil {
+				return nil, err
+			}
+		}
+		if len(doc) == 0 {
+			// the patch will fail anyway if the document doesn't exist
+			return nil, nil
+		}
+		targets = append(targets, doc)
+	} else {
+		var found []map[string]interface{}
+		if err := c.Query(ctx, p.Query, &found); err != nil {
+			return nil, err
+		}
+		for _, doc := range found {
+			if id, ok := doc["_id"].(string); ok {
+				if seen, ok := docs[id]; ok {
+					doc = seen
+				}
+			}
+			targets = append(targets, doc)
+		}
+	}
+
+	for _, doc := range targets {
+		if err := p.ApplyTo(doc); err != nil {
+			return nil, err
+		}
+		if id, ok := doc["_id"].(string); ok && id != "" {
+			docs[id] = doc
+		}
+	}
+	return targets, nil
+}
+
+func toDocMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
@@ -0,0 +1,121 @@
+package mpsanity
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mjm/mpsanity/patch"
+)
+
+func TestTxnValidate(t *testing.T) {
+	c, err := New("project")
+	assert.NoError(t, err)
+
+	c.AddValidator("post", ValidatorFunc(func(doc map[string]interface{}) error {
+		if _, ok := doc["slug"]; !ok {
+			return &ValidationError{Path: "slug", Message: "is required"}
+		}
+		return nil
+	}))
+	c.AddValidator("post", ValidatorFunc(func(doc map[string]interface{}) error {
+		return errors.New("always fails")
+	}))
+
+	err = c.Txn().
+		Create(map[string]interface{}{"_id": "a", "_type": "post"}).
+		Create(map[string]interface{}{"_id": "b", "_type": "micropost"}).
+		validate(context.Background())
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, ValidationErrors{
+		{
+			DocumentID:   "a",
+			DocumentType: "post",
+			Path:         "slug",
+			Message:      "is required",
+			Err:          &ValidationError{Path: "slug", Message: "is required"},
+		},
+		{DocumentID: "a", DocumentType: "post", Message: "always fails", Err: errors.New("always fails")},
+	}, errs)
+	assert.EqualError(t, err, `validation failed: post "a" slug: is required; post "a": always fails`)
+}
+
+func TestTxnValidateDoesNotChangeValidatorErrors(t *testing.T) {
+	c, err := New("project")
+	assert.NoError(t, err)
+
+	required := &ValidationError{Path: "slug", Message: "is required"}
+	c.AddValidator("post", ValidatorFunc(func(doc map[string]interface{}) error {
+		return required
+	}))
+
+	err = c.Txn().
+		Create(map[string]interface{}{"_id": "a", "_type": "post"}).
+		Create(map[string]interface{}{"_id": "b", "_type": "post"}).
+		validate(context.Background())
+	assert.EqualError(t, err, `validation failed: post "a" slug: is required; post "b" slug: is required`)
+	assert.True(t, errors.Is(err.(ValidationErrors)[0], required))
+	assert.Equal(t, &ValidationError{Path: "slug", Message: "is required"}, required)
+}
+
+func TestTxnValidatePatches(t *testing.T) {
+	requireSlug := ValidatorFunc(func(doc map[string]interface{}) error {
+		if _, ok := doc["slug"]; !ok {
+			return &ValidationError{Path: "slug", Message: "is required"}
+		}
+		return nil
+	})
+
+	t.Run("patch of a document created in the transaction", func(t *testing.T) {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request for %s", r.URL.Path)
+		})
+		c.AddValidator("post", requireSlug)
+
+		err := c.Txn().
+			Create(map[string]interface{}{"_id": "a", "_type": "post", "slug": "a"}).
+			Patch("a", patch.Set("title", "A")).
+			validate(context.Background())
+		assert.NoError(t, err)
+
+		err = c.Txn().
+			Create(map[string]interface{}{"_id": "a", "_type": "post", "slug": "a"}).
+			Patch("a", patch.Unset("slug")).
+			validate(context.Background())
+		assert.EqualError(t, err, `validation failed: post "a" slug: is required`)
+	})
+
+	t.Run("patch of an existing document", func(t *testing.T) {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/data/doc/production/b", r.URL.Path)
+			w.Write([]byte(`{"documents":[{"_id":"b","_type":"post","slug":"b","title":"B"}]}`))
+		})
+		c.AddValidator("post", requireSlug)
+
+		err := c.Txn().Patch("b", patch.Set("title", "New B")).validate(context.Background())
+		assert.NoError(t, err)
+
+		err = c.Txn().Patch("b", patch.Unset("slug")).validate(context.Background())
+		assert.EqualError(t, err, `validation failed: post "b" slug: is required`)
+	})
+
+	t.Run("patch by query", func(t *testing.T) {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/data/query/production", r.URL.Path)
+			w.Write([]byte(`{"result":[{"_id":"a","_type":"post","slug":"a"},{"_id":"c","_type":"post","slug":"c"}]}`))
+		})
+		c.AddValidator("post", requireSlug)
+
+		// the patch applies on top of the version of "a" created earlier in the transaction
+		err := c.Txn().
+			Create(map[string]interface{}{"_id": "a", "_type": "post"}).
+			PatchQuery(`*[_type == "post"]`, patch.Set("title", "T")).
+			validate(context.Background())
+		assert.EqualError(t, err, `validation failed: post "a" slug: is required; post "a" slug: is required`)
+	})
+}